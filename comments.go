@@ -0,0 +1,82 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+// func keepComments fills oc.Comments after a successful tokenization.
+// Every line that does not belong to an Item and is not empty is a
+// comment line; so are lines handled by line pragmas. The "// remark"
+// kept after a value (and its pragmas) is the Item's remark.
+func (oc *OcFlat) keepComments() {
+	b := oc.Inbuf
+	cs := make([]OcComment, 0, len(oc.Items)/4)
+	lead := -1 // first of the comment lines block that might lead an Item
+	var ln uint32 = 1
+	k := 0 // next Item
+	for p := 0; p < len(b); p++ {
+		e := lineEnd(b, p)
+		if k < len(oc.Items) && int(oc.Items[k].Ns) <= e {
+			it := &oc.Items[k]
+			for ; lead >= 0 && lead < len(cs); lead++ {
+				cs[lead].Item = k
+			}
+			if s, t := trimBlanks(b, int(it.Pe), e); t > s+1 && b[s] == '/' && b[s+1] == '/' {
+				cs = append(cs, OcComment{S: uint32(s), E: uint32(t), Line: ln, Item: k, Trail: true})
+			}
+			if it.Vs > it.Pe { // raw value lines go with the Item
+				for q := e + 1; q < int(it.Ve)+8; q = e + 1 {
+					e = lineEnd(b, q)
+					ln++
+				}
+			}
+			lead = -1
+			k++
+		} else if s, t := trimBlanks(b, p, e); s < t {
+			if lead < 0 {
+				lead = len(cs)
+			}
+			cs = append(cs, OcComment{S: uint32(s), E: uint32(t), Line: ln, Item: -1})
+		} else {
+			lead = -1 // empty line ends a block
+		}
+		p = e
+		ln++
+	}
+	oc.Comments = cs
+}
+
+// func lineEnd returns position of the newline that ends the line at p,
+// or len(b).
+func lineEnd(b []byte, p int) int {
+	for ; p < len(b) && b[p] != '\n'; p++ {
+	}
+	return p
+}
+
+// func trimBlanks returns s:e span with leading and trailing blanks (and
+// the CR) cut off.
+func trimBlanks(b []byte, s, e int) (int, int) {
+	for ; s < e && (b[s] == ' ' || b[s] == '\t'); s++ {
+	}
+	for ; e > s && (b[e-1] == ' ' || b[e-1] == '\t' || b[e-1] == '\r'); e-- {
+	}
+	return s, e
+}
+
+// CommentText returns the text of c, with its comment mark.
+func (oc *OcFlat) CommentText(c OcComment) []byte {
+	return oc.Inbuf[c.S:c.E:c.E]
+}
+
+// ItemComments returns comments that document the i-th Item: leading
+// comment lines, then the remark, if any. OcFlat.KeepComments must have
+// been set for Tokenize.
+func (oc *OcFlat) ItemComments(i int) (r []OcComment) {
+	for _, c := range oc.Comments {
+		if c.Item == i {
+			r = append(r, c)
+		}
+	}
+	return
+}
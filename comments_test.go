@@ -0,0 +1,60 @@
+package octok
+
+import "testing"
+
+const tComments string = `# free comment
+
+// leads key
+! and this too
+   key : value   // remark
+ other : v +.    // after pragma
+       : end
+ disa : a // b '.
+  raw :== RawBound  // raw remark
+   // not a comment
+RawBound
+  " trailing
+`
+
+func TestComments(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tComments)
+	for _, tok := range []func() bool{oc.Tokenize, func() bool { return TokenizeLint(&oc) }} {
+		Reset(&oc, nil, false)
+		oc.KeepComments = true
+		if ok := tok(); !ok {
+			t.Fatalf("Bad. Comments test config should parse but it did not!")
+		}
+		exp := []struct {
+			text  string
+			line  uint32
+			item  int
+			trail bool
+		}{
+			{"# free comment", 1, -1, false},
+			{"// leads key", 3, 0, false},
+			{"! and this too", 4, 0, false},
+			{"// remark", 5, 0, true},
+			{"// after pragma", 6, 1, true},
+			{"// raw remark", 9, 4, true},
+			{`" trailing`, 12, -1, false},
+		}
+		if len(oc.Comments) != len(exp) {
+			t.Fatalf("Bad. Expected %d comments, got %d", len(exp), len(oc.Comments))
+		}
+		for n, e := range exp {
+			c := oc.Comments[n]
+			if s := string(oc.CommentText(c)); s != e.text || c.Line != e.line || c.Item != e.item || c.Trail != e.trail {
+				t.Errorf("Bad comment %d: »%s« %d %d %v", n, s, c.Line, c.Item, c.Trail)
+			}
+		}
+		if ic := oc.ItemComments(0); len(ic) != 3 || !ic[2].Trail {
+			t.Errorf("Bad. Item 0 should have 3 comments, got %d", len(ic))
+		}
+	}
+	Reset(&oc, nil, false)
+	oc.KeepComments = false
+	if oc.Tokenize(); oc.Comments != nil {
+		t.Errorf("Bad. Comments should not be kept unless asked for")
+	}
+}
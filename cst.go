@@ -0,0 +1,135 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"bytes"
+	"errors"
+)
+
+// OcCST keeps the config text split into lines, so that every byte of
+// the Inbuf, be it an indent, a comment or a pragma, has its place. It
+// allows to change Item values and print the config back with all other
+// bytes kept as they were. It is used like OcTree is:
+//
+//	var c octok.OcCST
+//	c.Oc = &oc // after successful oc.Tokenize()
+//	c.Build()
+//	if err := c.SetValue(3, []byte("new value")); err != nil {
+//		// value would not parse back as given
+//	}
+//	out := c.Bytes()
+type OcCST struct {
+	Oc    *OcFlat   // tokenized config
+	Lines []OcCLine // config text, in order
+}
+
+// OcCLine is a line of the config text. Line of an Item is split into
+// the text before the value, the value and the text after it; raw value
+// lines go with the Item too. Empty lines and comment lines are kept
+// whole in the Head.
+type OcCLine struct {
+	Item int    // Item given on the line, or -1
+	Head []byte // indent, name and separator; or the whole not Item line
+	Val  []byte // value, as given
+	Tail []byte // pragmas, metas, remark; raw block boundary; newline
+}
+
+// Build splits the Oc.Inbuf into Lines. Lines refer to the Inbuf until
+// changed by the SetValue.
+func (c *OcCST) Build() {
+	oc := c.Oc
+	b := oc.Inbuf
+	c.Lines = make([]OcCLine, 0, len(oc.Items)+len(oc.Items)/2)
+	k := 0 // next Item
+	for p := 0; p < len(b); {
+		e := lineEnd(b, p)
+		if k < len(oc.Items) && int(oc.Items[k].Ns) <= e {
+			it := &oc.Items[k]
+			if it.Vs > it.Pe { // raw block ends with the boundary line
+				e = lineEnd(b, int(it.Ve)+8)
+			}
+			if e < len(b) {
+				e++
+			}
+			vs, ve := int(it.Vs), int(it.Ve)
+			c.Lines = append(c.Lines, OcCLine{Item: k,
+				Head: b[p:vs:vs], Val: b[vs:ve:ve], Tail: b[ve:e:e]})
+			k++
+		} else {
+			if e < len(b) {
+				e++
+			}
+			c.Lines = append(c.Lines, OcCLine{Item: -1, Head: b[p:e:e]})
+		}
+		p = e
+	}
+}
+
+// Bytes returns the config text. For a not changed OcCST it is equal to
+// the Oc.Inbuf, byte for byte.
+func (c *OcCST) Bytes() []byte {
+	n := 0
+	for _, l := range c.Lines {
+		n += len(l.Head) + len(l.Val) + len(l.Tail)
+	}
+	r := make([]byte, 0, n)
+	for _, l := range c.Lines {
+		r = append(append(append(r, l.Head...), l.Val...), l.Tail...)
+	}
+	return r
+}
+
+// SetValue changes the value of the i-th Item to val. Only bytes of the
+// value change; if the separator is not followed by a blank (as for an
+// empty value) a space is put after it. The changed line is tokenized
+// again and SetValue errs if it would not give the same Item name, pragmas
+// and metas with the val as the value, eg. if val has a newline, or ends
+// with what looks like a pragma, or if it holds a " // " remark mark on a
+// line without the disa pragma. Raw value must not hold its boundary.
+// Errors are of *OcError type. Oc.Items are not updated: tokenize the
+// Bytes for that.
+func (c *OcCST) SetValue(i int, val []byte) error {
+	oc := c.Oc
+	x := -1
+	for n := range c.Lines { // Items are in order, so is their Lines
+		if c.Lines[n].Item == i {
+			x = n
+			break
+		}
+	}
+	if x < 0 {
+		return &OcError{Msg: "no such Item"}
+	}
+	l := c.Lines[x]
+	head := l.Head
+	if len(val) > 0 && val[0] != ' ' && val[0] != '\t' &&
+		len(head) > 0 && head[len(head)-1] == ':' {
+		head = append(head[:len(head):len(head)], ' ')
+	}
+	var t OcFlat
+	t.NoTypes, t.NoMetas, t.AllowBinRaw = oc.NoTypes, oc.NoMetas, oc.AllowBinRaw
+	t.Inbuf = make([]byte, 0, len(head)+len(val)+len(l.Tail)+1)
+	t.Inbuf = append(append(append(t.Inbuf, head...), val...), l.Tail...)
+	if len(l.Tail) == 0 || l.Tail[len(l.Tail)-1] != '\n' {
+		t.Inbuf = append(t.Inbuf, '\n') // last line lacking a newline
+	}
+	err := errors.New("line would not tokenize back")
+	switch {
+	case !t.Tokenize() || len(t.Items) != 1:
+	case !bytes.Equal(t.Name(0), oc.Name(i)):
+		err = errors.New("name would change")
+	case !bytes.Equal(t.RawValue(0), val):
+		err = errors.New("value would not parse as given")
+	case t.PragmasStr(0) != oc.PragmasStr(i) || t.MetasStr(0) != oc.MetasStr(i):
+		err = errors.New("pragmas would change")
+	default:
+		l.Head = head
+		l.Val = append([]byte(nil), val...)
+		c.Lines[x] = l
+		return nil
+	}
+	return &OcError{Pos: oc.Items[i].Vs, Line: oc.ItemLine(i), Msg: err.Error()}
+}
@@ -0,0 +1,85 @@
+package octok
+
+import (
+	"bytes"
+	"testing"
+)
+
+const tCST string = "# leading comment\r\n" +
+	"\n" +
+	" ^ Section : ---- lead  // remark\n" +
+	"    spaced :  val & spaces     |.\n" +
+	"     empty :\n" +
+	"    looong : value can span    +.   //  +  join\n" +
+	"           :: still keep indent.\n" +
+	"      meta : tagged ^@one;.\n" +
+	"       raw :== RawBound\n" +
+	"  raw text \n" +
+	"RawBound tail\n" +
+	"\t   last : one\n" +
+	"// no newline at the end"
+
+func TestCSTRoundTrip(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tCST)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. CST test config should parse but it did not! %v", oc.BadLint)
+	}
+	c := OcCST{Oc: &oc}
+	c.Build()
+	if out := c.Bytes(); !bytes.Equal(out, oc.Inbuf) {
+		t.Fatalf("Bad. Not changed CST should print as read.\nExpected: %q\n     Got: %q", oc.Inbuf, out)
+	}
+	if len(c.Lines) != 11 || c.Lines[8].Item != 6 || string(c.Lines[8].Val) != "  raw text \n" {
+		t.Errorf("Bad. Raw block should be on a single line, got %d lines", len(c.Lines))
+	}
+	for _, te := range []struct {
+		i   int
+		val string
+	}{
+		{1, "  new & blanks  "},
+		{2, "now set"},
+		{4, " another"},
+		{5, "changed"},
+		{6, "new\nraw block\n"},
+	} {
+		if err := c.SetValue(te.i, []byte(te.val)); err != nil {
+			t.Errorf("Bad. Item %d should be set to »%q« but it was not: %s", te.i, te.val, err)
+		}
+	}
+	exp := []byte(tCST)
+	for _, r := range [][2]string{
+		{"  val & spaces     |.", "   new & blanks  |."},
+		{"empty :\n", "empty : now set\n"},
+		{" still keep indent.", " another"},
+		{"tagged ^@one;.", "changed ^@one;."},
+		{"  raw text \n", "new\nraw block\n"},
+	} {
+		exp = bytes.Replace(exp, []byte(r[0]), []byte(r[1]), 1)
+	}
+	if out := c.Bytes(); !bytes.Equal(out, exp) {
+		t.Errorf("Bad. Changed CST printed wrong.\nExpected: %q\n     Got: %q", exp, out)
+	}
+	Reset(&oc, c.Bytes(), false)
+	if ok := oc.Tokenize(); !ok || oc.RawValueStr(1) != "  new & blanks  " || oc.RawValueStr(6) != "new\nraw block\n" {
+		t.Errorf("Bad. Changed CST should tokenize back with new values")
+	}
+	c.Build()
+	for _, te := range []struct {
+		i   int
+		val string
+	}{
+		{0, "two\nlines"},
+		{0, "looks +."},
+		{7, "with // remark"},
+		{6, "has RawBound in"},
+		{9, "no such item"},
+	} {
+		if err := c.SetValue(te.i, []byte(te.val)); err == nil {
+			t.Errorf("Bad. Item %d should not be set to »%q« but it was!", te.i, te.val)
+		}
+	}
+	if out := c.Bytes(); !bytes.Equal(out, oc.Inbuf) {
+		t.Errorf("Bad. Failed SetValue should not change the text.")
+	}
+}
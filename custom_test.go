@@ -0,0 +1,105 @@
+package octok
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tSize is a byte size given in KiB, with the unit meta kept.
+type tSize struct {
+	N    uint64
+	Unit string
+	Tc   OcItemTc
+	Raw  string
+}
+
+func (s *tSize) UnmarshalOconf(raw []byte, lv OcLogical) (err error) {
+	s.Raw, s.Tc = string(raw), lv.Tc
+	if len(lv.Metas) > 0 {
+		s.Unit = string(lv.Metas[0])
+	}
+	v := strings.TrimSuffix(string(lv.Val), "KiB")
+	if s.N, err = strconv.ParseUint(v, 10, 64); err != nil {
+		return errors.New("bad size " + string(lv.Val))
+	}
+	s.N <<= 10
+	return
+}
+
+func (s tSize) MarshalOconf() (val []byte, pragmas string, err error) {
+	if s.Unit != "" {
+		pragmas = "$" + s.Unit
+	}
+	return []byte(strconv.FormatUint(s.N>>10, 10) + "KiB"), pragmas, nil
+}
+
+// tCron is a cron spec, given as text.
+type tCron []string
+
+func (c *tCron) UnmarshalText(b []byte) error {
+	if *c = strings.Fields(string(b)); len(*c) != 5 {
+		return errors.New("cron spec needs 5 fields")
+	}
+	return nil
+}
+
+func (c tCron) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(c, " ")), nil
+}
+
+type tCustomConf struct {
+	Size  tSize
+	Sizes []tSize
+	Cron  *tCron
+	Crons map[string]tCron
+}
+
+func TestCustomTypes(t *testing.T) {
+	conf := `  Size : 10KiB  $@unit;.
+ Sizes [ :
+       : 1KiB
+       ] :
+  Cron : */5 * * * *
+ Crons { :
+ daily : 0 3 * * *
+     } :
+`
+	var c tCustomConf
+	if err := Unmarshal([]byte(conf), &c); err != nil {
+		t.Fatalf("Bad. Custom config should unmarshal but it did not: %s", err)
+	}
+	exp := tCustomConf{
+		Size:  tSize{N: 10240, Unit: "@unit;", Tc: '$', Raw: "10KiB"},
+		Sizes: []tSize{{N: 1024, Raw: "1KiB"}},
+		Cron:  &tCron{"*/5", "*", "*", "*", "*"},
+		Crons: map[string]tCron{"daily": {"0", "3", "*", "*", "*"}},
+	}
+	if !reflect.DeepEqual(c, exp) {
+		t.Errorf("Bad custom unmarshal.\nExpected: %+v\n     Got: %+v", exp, c)
+	}
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatalf("Bad. Custom types should marshal but they did not: %s", err)
+	}
+	if !strings.HasPrefix(string(out), "Size : 10KiB $@unit;.\n") {
+		t.Errorf("Bad. Custom pragmas should be written:\n%s", out)
+	}
+	var back tCustomConf
+	if err := Unmarshal(out, &back); err != nil || !reflect.DeepEqual(back, exp) {
+		t.Errorf("Bad. Custom types should read back (%v):\n%s", err, out)
+	}
+	for _, bad := range []string{
+		"Size : 10MiB\n",
+		"Cron : * *\n",
+		"^ Size : ---\n",
+	} {
+		if err := Unmarshal([]byte(bad), &c); err == nil {
+			t.Errorf("Bad. »%s« should not unmarshal but it did!", bad)
+		} else if e, ok := err.(*OcError); !ok || e.Line != 1 {
+			t.Errorf("Bad. »%s« should err at line 1, got: %v", bad, err)
+		}
+	}
+}
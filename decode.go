@@ -0,0 +1,527 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Unmarshal parses the OCONF data and stores the result in the value
+// pointed to by v. See OcDecoder Decode for how it is done.
+func Unmarshal(data []byte, v interface{}) error {
+	var d OcDecoder
+	return d.Decode(data, v)
+}
+
+// OcDecoder decodes OCONF data into Go values. Its Oc and Tree knobs (eg.
+// Oc.NoTypes, Oc.Resolvers or Tree.Dups) can be set before Decode is
+// called. After Decode, Oc and Tree keep the config that was decoded.
+type OcDecoder struct {
+	Oc          OcFlat   // tokenizer, with its knobs
+	Tree        OcTree   // config structure, with its knobs
+	TimeLayouts []string // time.Parse layouts tried after the RFC3339 one
+}
+
+// Decode tokenizes data, builds its structure, then stores it in the
+// value pointed to by v:
+//
+// Sections, dicts and sets go into structs and maps. Struct field gets the
+// member of the name given by its `oconf:"name"` tag, or of its own name
+// (matched exact first, then case insensitive). Fields tagged `oconf:"-"`
+// and not exported fields are left alone, fields of embedded structs are
+// filled as if they were given in the outer struct. Names are compared in
+// the canonical form, as Build compares them, and a dotted name no field
+// takes, as "a.b", goes into the b field of the a struct field, as Lookup
+// finds it. Maps of string keys get named members, and ORD members keyed
+// "[n]"; maps of integer keys get ORD members only. Lists, and ORD members
+// of other containers, go into slices and arrays, at their index.
+//
+// Types that implement Unmarshaler or encoding.TextUnmarshaler decode
+// values on their own, see Unmarshaler. Values of some Go library types
+// are decoded as the type parse function tells: time.Duration ("30s",
+// "1h30m"), time.Time (RFC3339, then the TimeLayouts), net.IP, net.IPNet
+// (CIDR, "10.0.0.0/8") and url.URL. Human byte sizes, eg. "64KiB" or
+// "1.5GB", go into the ByteSize.
+//
+// Values go into bools, numbers, strings and []byte, with their pragmas
+// (unescape, joins, carets, types) applied. Typed values must fit the
+// Go type, untyped ones are converted as their type would tell. Words
+// and comma lists go into slices. Value of the "-" type leaves Go value
+// zeroed, and pointers nil. Other pointers are allocated as needed.
+//
+// Into an empty interface, as into a map[string]interface{}, config
+// goes untyped: sections, dicts and sets as map[string]interface{} with
+// ORD members keyed "[n]", lists as []interface{} with nil in the gaps,
+// and values as Typed gives them (strings if untyped). Such a result
+// can be given to the encoding/json Marshal as is.
+//
+// Keys that have no place in v are skipped, so are model sections. Errors
+// returned are of *OcError type, carrying the config line.
+func (d *OcDecoder) Decode(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("octok: Decode needs a not nil pointer")
+	}
+	Reset(&d.Oc, data, false)
+	if !d.Oc.Tokenize() {
+		bl := d.Oc.BadLint
+		if bl.What == LintOK {
+			bl.What = LintBadBufLen
+		}
+		return &OcError{Line: bl.Line, Msg: strings.TrimPrefix(LintMessage(bl.What), " ‣ ")}
+	}
+	d.Tree.Oc = &d.Oc
+	if err := d.Tree.Build(); err != nil {
+		return err
+	}
+	for _, r := range d.Oc.Resolvers {
+		if kr, ok := r.(*KeyResolver); ok {
+			kr.Tree = &d.Tree
+		}
+	}
+	return d.node(d.Tree.Root, rv.Elem())
+}
+
+// func node stores n in v.
+func (d *OcDecoder) node(n *OcNode, v reflect.Value) error {
+	if stdTypes[v.Type()] {
+		return d.stdLeaf(n, v)
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && v.Addr().CanInterface() {
+		if done, err := d.custom(n, v.Addr()); done {
+			return err
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if n.Kind == NodeLeaf && !d.Oc.NoTypes && d.Oc.itemType(n.Target().Item) == TcNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.node(n, v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			x, err := d.untyped(n)
+			if err == nil && x != nil {
+				v.Set(reflect.ValueOf(x))
+			}
+			return err
+		}
+	case reflect.Struct:
+		if n.Kind != NodeLeaf {
+			return d.toStruct(n, v)
+		}
+	case reflect.Map:
+		if n.Kind != NodeLeaf {
+			return d.toMap(n, v)
+		}
+	case reflect.Slice, reflect.Array:
+		if n.Kind != NodeLeaf {
+			return d.toSlice(n, v)
+		}
+	}
+	if n.Kind == NodeLeaf {
+		return d.leaf(n, v)
+	}
+	return d.errAt(n, "can not put a "+kindName[n.Kind]+" into "+v.Type().String())
+}
+
+// func custom lets the value pv points to decode n on its own, if it
+// implements Unmarshaler or encoding.TextUnmarshaler.
+func (d *OcDecoder) custom(n *OcNode, pv reflect.Value) (done bool, err error) {
+	switch pv.Interface().(type) {
+	case Unmarshaler, encoding.TextUnmarshaler:
+	default:
+		return
+	}
+	if n.Kind != NodeLeaf {
+		return true, d.errAt(n, "can not put a "+kindName[n.Kind]+" into "+pv.Elem().Type().String())
+	}
+	i := n.Target().Item
+	lv, err := d.Oc.Logical(i)
+	if err != nil {
+		return true, err
+	}
+	if d.Oc.NoTypes {
+		lv.Tc = 0
+	}
+	switch u := pv.Interface().(type) {
+	case Unmarshaler:
+		err = u.UnmarshalOconf(d.Oc.RawValue(i), lv)
+	case encoding.TextUnmarshaler:
+		err = u.UnmarshalText(lv.Val)
+	}
+	if _, ok := err.(*OcError); err != nil && !ok {
+		err = d.errAt(n, err.Error())
+	}
+	return true, err
+}
+
+// kindName names node kinds in errors.
+var kindName = [...]string{"config", "section", "value", "group", "dict", "list", "set", "model"}
+
+// func untyped returns n as a map[string]interface{} (sections, dicts
+// and sets), []interface{} (lists) or the typed value (leaves).
+func (d *OcDecoder) untyped(n *OcNode) (x interface{}, err error) {
+	switch n.Kind {
+	case NodeLeaf:
+		x, _, err = d.value(n)
+		return
+	case NodeList:
+		dl := n.Dense()
+		l := make([]interface{}, len(dl))
+		for k, m := range dl {
+			if m != nil {
+				if l[k], err = d.untyped(m); err != nil {
+					return
+				}
+			}
+		}
+		return l, nil
+	}
+	m := make(map[string]interface{})
+	for _, k := range n.members() {
+		if k.Kind == NodeModel {
+			continue
+		}
+		key := k.Name
+		if k.Index >= 0 {
+			key = "[" + strconv.Itoa(k.Index) + "]"
+		}
+		if m[key], err = d.untyped(k); err != nil {
+			return
+		}
+	}
+	return m, nil
+}
+
+// func toStruct stores named members of n in fields of the v struct.
+func (d *OcDecoder) toStruct(n *OcNode, v reflect.Value) (err error) {
+	n.walkNamed(func(k *OcNode) {
+		if err != nil || k.Kind == NodeModel {
+			return
+		}
+		name := canonName(k.Name)
+		if f := fieldOf(v, name); f.IsValid() {
+			err = d.node(k, f)
+		} else {
+			_, err = d.dotted(k, v, name)
+		}
+	})
+	return
+}
+
+// func dotted stores k, of the dotted name that no field of the v struct
+// takes, in a field of a struct field, splitting the name at a dot as
+// Lookup does. It returns false if no field takes k.
+func (d *OcDecoder) dotted(k *OcNode, v reflect.Value, name string) (bool, error) {
+	for x := 0; x < len(name); x++ {
+		if name[x] != '.' {
+			continue
+		}
+		f, p := fieldOf(v, name[:x]), reflect.Value{}
+		if f.Kind() == reflect.Ptr && f.Type().Elem().Kind() == reflect.Struct {
+			if p, f = f, f.Elem(); p.IsNil() {
+				f = reflect.New(p.Type().Elem()).Elem()
+			}
+		}
+		if f.Kind() != reflect.Struct || stdTypes[f.Type()] {
+			continue
+		}
+		ok, err := true, error(nil)
+		if g := fieldOf(f, name[x+1:]); g.IsValid() {
+			err = d.node(k, g)
+		} else {
+			ok, err = d.dotted(k, f, name[x+1:])
+		}
+		if ok {
+			if p.IsValid() && p.IsNil() {
+				p.Set(f.Addr())
+			}
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// func fieldOf returns the field of the v struct the name goes to, or
+// an invalid Value.
+func fieldOf(v reflect.Value, name string) reflect.Value {
+	var fold reflect.Value
+	t := v.Type()
+	for x := 0; x < t.NumField(); x++ {
+		sf := t.Field(x)
+		tag := sf.Tag.Get("oconf")
+		if c := strings.IndexByte(tag, ','); c >= 0 {
+			tag = tag[:c]
+		}
+		switch {
+		case tag == "-", sf.PkgPath != "" && !sf.Anonymous:
+			continue
+		case sf.Anonymous && tag == "" && sf.Type.Kind() == reflect.Struct:
+			if f := fieldOf(v.Field(x), name); f.IsValid() {
+				return f
+			}
+			continue
+		case sf.PkgPath != "":
+			continue
+		case tag != "":
+			if canonName(tag) == name {
+				return v.Field(x)
+			}
+		case sf.Name == name:
+			return v.Field(x)
+		case !fold.IsValid() && strings.EqualFold(sf.Name, name):
+			fold = v.Field(x)
+		}
+	}
+	return fold
+}
+
+// func toMap stores members of n in the v map.
+func (d *OcDecoder) toMap(n *OcNode, v reflect.Value) (err error) {
+	t := v.Type()
+	var strKey bool
+	switch t.Key().Kind() {
+	case reflect.String:
+		strKey = true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return d.errAt(n, "can not use "+t.String()+" for a "+kindName[n.Kind])
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	put := func(k *OcNode, key reflect.Value) {
+		e := reflect.New(t.Elem()).Elem()
+		if err = d.node(k, e); err == nil {
+			v.SetMapIndex(key, e)
+		}
+	}
+	for _, k := range n.members() {
+		switch {
+		case err != nil:
+			return
+		case k.Kind == NodeModel:
+		case k.Index < 0 && strKey:
+			put(k, reflect.ValueOf(k.Name).Convert(t.Key()))
+		case k.Index < 0:
+		case strKey:
+			put(k, reflect.ValueOf("["+strconv.Itoa(k.Index)+"]").Convert(t.Key()))
+		default:
+			key := reflect.New(t.Key()).Elem()
+			if key.Kind() >= reflect.Uint {
+				key.SetUint(uint64(k.Index))
+			} else {
+				key.SetInt(int64(k.Index))
+			}
+			put(k, key)
+		}
+	}
+	return
+}
+
+// func members returns members of n, looking through groups.
+func (n *OcNode) members() (r []*OcNode) {
+	for _, k := range n.Kids {
+		if k.Kind == NodeGroup {
+			r = append(r, k.members()...)
+		} else {
+			r = append(r, k)
+		}
+	}
+	return
+}
+
+// func toSlice stores ORD members of n in the v slice or array, at their
+// index.
+func (d *OcDecoder) toSlice(n *OcNode, v reflect.Value) error {
+	dl := n.Dense()
+	if v.Kind() == reflect.Array {
+		if len(dl) > v.Len() {
+			return d.errAt(n, "index "+strconv.Itoa(len(dl)-1)+" does not fit "+v.Type().String())
+		}
+	} else {
+		v.Set(reflect.MakeSlice(v.Type(), len(dl), len(dl)))
+	}
+	for x, k := range dl {
+		if k == nil {
+			continue
+		}
+		if err := d.node(k, v.Index(x)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func value returns the typed value of the n leaf and its logical value.
+func (d *OcDecoder) value(n *OcNode) (tv interface{}, lv OcLogical, err error) {
+	oc := d.Tree.Oc
+	i := n.Target().Item
+	if lv, err = oc.Logical(i); err != nil {
+		return
+	}
+	if oc.NoTypes {
+		lv.Tc = 0
+	}
+	if tv, err = TypedValue(lv.Tc, lv.Val); err != nil {
+		err = &OcError{Pos: oc.Items[i].Vs, Line: oc.ItemLine(i), Msg: err.(*OcError).Msg}
+	}
+	return
+}
+
+// func leaf stores the value of the n leaf in v.
+func (d *OcDecoder) leaf(n *OcNode, v reflect.Value) error {
+	tv, lv, err := d.value(n)
+	if err != nil {
+		return err
+	}
+	if lv.Tc == TcNull {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		v.SetBytes(append([]byte(nil), lv.Val...))
+		return nil
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		l, ok := tv.([]string)
+		if !ok {
+			if l = []string{string(lv.Val)}; len(lv.Val) == 0 {
+				l = nil
+			}
+		}
+		if v.Kind() == reflect.Array && len(l) > v.Len() {
+			return d.errAt(n, strconv.Itoa(len(l))+" elements do not fit "+v.Type().String())
+		} else if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(l), len(l)))
+		}
+		for x, s := range l {
+			if err := d.word(n, v.Index(x), s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return d.scalar(n, v, tv, string(lv.Val))
+}
+
+// func word stores s, an element of the n words or comma list, in v.
+func (d *OcDecoder) word(n *OcNode, v reflect.Value, s string) error {
+	if stdTypes[v.Type()] {
+		return d.std(n, v, s)
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return d.errAt(n, err.Error())
+		}
+		return nil
+	}
+	return d.scalar(n, v, s, s)
+}
+
+// func scalar stores tv value, given as s in the config, in v.
+func (d *OcDecoder) scalar(n *OcNode, v reflect.Value, tv interface{}, s string) (err error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.scalar(n, v.Elem(), tv, s)
+	}
+	if _, ok := tv.(string); ok { // untyped, convert as the Go type tells
+		switch v.Kind() {
+		case reflect.Bool:
+			tv, err = TypedValue(TcBool, []byte(s))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			tv, err = TypedValue(TcInt, []byte(s))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			tv, err = TypedValue(TcUint, []byte(s))
+		case reflect.Float32, reflect.Float64:
+			tv, err = TypedValue(TcFloat, []byte(s))
+		}
+		if err != nil {
+			return d.errAt(n, err.(*OcError).Msg)
+		}
+	}
+	bad := func() error {
+		return d.errAt(n, "value »"+s+"« does not fit "+v.Type().String())
+	}
+	switch x := tv.(type) {
+	case string:
+		if v.Kind() != reflect.String && (v.Kind() != reflect.Interface || v.NumMethod() != 0) {
+			return bad()
+		}
+		v.Set(reflect.ValueOf(x).Convert(v.Type()))
+	case bool:
+		if v.Kind() != reflect.Bool {
+			return bad()
+		}
+		v.SetBool(x)
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(x) {
+				return bad()
+			}
+			v.SetInt(x)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if x < 0 || v.OverflowUint(uint64(x)) {
+				return bad()
+			}
+			v.SetUint(uint64(x))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(x))
+		default:
+			return bad()
+		}
+	case uint64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if x > 1<<63-1 || v.OverflowInt(int64(x)) {
+				return bad()
+			}
+			v.SetInt(int64(x))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.OverflowUint(x) {
+				return bad()
+			}
+			v.SetUint(x)
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(x))
+		default:
+			return bad()
+		}
+	case float64:
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 || v.OverflowFloat(x) {
+			return bad()
+		}
+		v.SetFloat(x)
+	case []string:
+		if v.Kind() != reflect.String {
+			return bad()
+		}
+		v.SetString(s)
+	default:
+		return bad()
+	}
+	return nil
+}
+
+// func errAt makes an *OcError pointing at the n node.
+func (d *OcDecoder) errAt(n *OcNode, msg string) error {
+	if n.Item < 0 {
+		return &OcError{Msg: msg}
+	}
+	return d.Tree.errAt(n.Item, msg)
+}
@@ -0,0 +1,185 @@
+package octok
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tDecode string = `   name : demo app
+  debug : yes
+  ratio : 0.25  ~.
+  ^ Server : ---
+      host : example.com
+      port : 8080  #.
+   'a key : spaced
+     ports [ :
+           : 80
+           : 443
+           ] :
+      tags : a b c  *.
+      text : line\tone \^.
+      nope : not in the struct
+   ^^ Limits : ---
+     conns : 100
+      rate : 1.5
+  ^ Extra : ---
+    first : 1
+      two : 2
+        : ord
+`
+
+type tLimits struct {
+	Conns uint16
+	Rate  float32
+}
+
+type tEmbed struct {
+	Debug bool
+}
+
+type tServer struct {
+	Host   string
+	Port   int
+	AKey   string `oconf:"a key"`
+	Ports  []int
+	Tags   []string
+	Text   []byte
+	Limits *tLimits
+	Skip   string `oconf:"-"`
+}
+
+type tDecodeConf struct {
+	tEmbed
+	Name   string
+	Ratio  float64
+	Server tServer
+	Extra  map[string]string
+	hidden string
+}
+
+func TestUnmarshal(t *testing.T) {
+	var c tDecodeConf
+	if err := Unmarshal([]byte(tDecode), &c); err != nil {
+		t.Fatalf("Bad. Decode test config should unmarshal but it did not: %s", err)
+	}
+	exp := tDecodeConf{
+		tEmbed: tEmbed{Debug: true},
+		Name:   "demo app",
+		Ratio:  0.25,
+		Server: tServer{
+			Host:   "example.com",
+			Port:   8080,
+			AKey:   "spaced",
+			Ports:  []int{80, 443},
+			Tags:   []string{"a", "b", "c"},
+			Text:   []byte("line\tone\n"),
+			Limits: &tLimits{Conns: 100, Rate: 1.5},
+		},
+		Extra: map[string]string{"first": "1", "two": "2", "[0]": "ord"},
+	}
+	if !reflect.DeepEqual(c, exp) {
+		t.Errorf("Bad unmarshal.\nExpected: %+v\n     Got: %+v", exp, c)
+	}
+	var m struct {
+		Server struct{ Ports map[int]uint16 }
+		Extra  [2]string
+	}
+	if err := Unmarshal([]byte(tDecode), &m); err != nil || m.Server.Ports[1] != 443 || m.Server.Ports[0] != 80 {
+		t.Errorf("Bad. Ports should go into a map of int keys (%v)", err)
+	}
+	if m.Extra[0] != "ord" {
+		t.Errorf("Bad. ORD member should go into an array")
+	}
+	for _, bad := range []struct {
+		conf string
+		line uint32
+		msg  string
+	}{
+		{"port : 99999\n", 1, "does not fit uint16"},
+		{"port : -1\n", 1, "bad"},
+		{"port : 12x\n", 1, "bad"},
+		{"port : 12  ~.\n", 1, "does not fit uint16"},
+		{"^ Port : ---\n", 1, "can not put a section"},
+		{"k : v +.\n", 1, "join"},
+		{"k : v\nk : w\n", 2, "duplicate"},
+		{"k : v\nk : w", 2, "no nl"},
+	} {
+		var p struct{ Port uint16 }
+		conf := bad.conf
+		if err := Unmarshal([]byte(conf), &p); err == nil {
+			t.Errorf("Bad. »%s« should not unmarshal but it did!", conf)
+		} else if e := err.(*OcError); e.Line != bad.line || !strings.Contains(strings.ToLower(e.Msg), bad.msg) {
+			t.Errorf("Bad. »%s« should err at line %d with %q, got: %s", conf, bad.line, bad.msg, err)
+		}
+	}
+	var dk struct {
+		A struct{ B string }
+		P *struct{ C, D string }
+		N *struct{ E string }
+		K string `oconf:"a key"`
+	}
+	if err := Unmarshal([]byte("A.B : v\nP . C : w\na  key : x\nN.x : y\n"), &dk); err != nil ||
+		dk.A.B != "v" || dk.P == nil || dk.P.C != "w" || dk.K != "x" || dk.N != nil {
+		t.Errorf("Bad. Dotted and spaced names should go into fields: %+v (%v)", dk, err)
+	}
+	var si struct{ X fmt.Stringer }
+	if err := Unmarshal([]byte("X : v\n"), &si); err == nil || !strings.Contains(err.Error(), "does not fit") {
+		t.Errorf("Bad. Value should not fit an interface with methods, got: %v", err)
+	}
+	var mi map[string]fmt.Stringer
+	if err := Unmarshal([]byte("X : v\n"), &mi); err == nil || !strings.Contains(err.Error(), "does not fit") {
+		t.Errorf("Bad. Value should not fit a map of interfaces with methods, got: %v", err)
+	}
+	if err := Unmarshal([]byte(tDecode), c); err == nil {
+		t.Errorf("Bad. Not a pointer should err but it did not!")
+	}
+}
+
+const tUntyped string = `   name : demo
+  ^ Section : ---
+      port : 8080  #.
+      tags : a b  *.
+     items [ :
+           : zero
+         2 : two
+           ] :
+      mixed < :
+           : ord
+       key : named
+           > :
+      none : -.
+  @ Model : ---
+      skip : me
+`
+
+func TestUnmarshalUntyped(t *testing.T) {
+	var m map[string]interface{}
+	if err := Unmarshal([]byte(tUntyped), &m); err != nil {
+		t.Fatalf("Bad. Untyped test config should unmarshal but it did not: %s", err)
+	}
+	exp := map[string]interface{}{
+		"name": "demo",
+		"Section": map[string]interface{}{
+			"port":  int64(8080),
+			"tags":  []string{"a", "b"},
+			"items": []interface{}{"zero", nil, "two"},
+			"mixed": map[string]interface{}{"[0]": "ord", "key": "named"},
+			"none":  nil,
+		},
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Errorf("Bad untyped unmarshal.\nExpected: %#v\n     Got: %#v", exp, m)
+	}
+	var x interface{}
+	if err := Unmarshal([]byte(tUntyped), &x); err != nil || !reflect.DeepEqual(x, exp) {
+		t.Errorf("Bad. Unmarshal into an interface should give the same (%v)", err)
+	}
+	js, err := json.Marshal(m)
+	if err != nil || string(js) != `{"Section":{"items":["zero",null,"two"],"mixed":{"[0]":"ord","key":"named"},`+
+		`"none":null,"port":8080,"tags":["a","b"]},"name":"demo"}` {
+		t.Errorf("Bad JSON export: %s (%v)", js, err)
+	}
+}
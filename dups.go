@@ -0,0 +1,145 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"sort"
+	"strings"
+)
+
+// func dedup finds keys given more than once among members of n and of
+// every container below, then deals with them as the Dups tell.
+func (t *OcTree) dedup(n *OcNode) error {
+	if n.Kind == NodeLeaf {
+		return nil
+	}
+	if n.Kind != NodeGroup && n.Kind != NodeList {
+		var names []string
+		seen := make(map[string][]*OcNode)
+		n.walkNamed(func(k *OcNode) {
+			c := canonName(k.Name)
+			if seen[c] == nil {
+				names = append(names, c)
+			}
+			seen[c] = append(seen[c], k)
+		})
+		for _, c := range names {
+			dl := append([]*OcNode(nil), seen[c]...)
+			for d := 0; d < len(c); d++ {
+				if c[d] != '.' {
+					continue
+				}
+				for _, up := range seen[c[:d]] {
+					if o := up.named(c[d+1:]); o != nil && !hasNode(dl, o) {
+						dl = append(dl, o)
+					}
+				}
+			}
+			if len(dl) > 1 {
+				sort.Slice(dl, func(a, b int) bool { return dl[a].Item < dl[b].Item })
+				if err := t.dupKey(c, dl); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, k := range n.Kids {
+		if err := t.dedup(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func dupKey lints and resolves the dl duplicates of the c key.
+func (t *OcTree) dupKey(c string, dl []*OcNode) error {
+	for _, k := range dl {
+		t.Oc.lint(k.Item, LintDupKey)
+	}
+	switch t.Dups {
+	case DupLast:
+		for _, k := range dl[:len(dl)-1] {
+			k.Up.drop(k)
+		}
+	case DupFirst:
+		for _, k := range dl[1:] {
+			k.Up.drop(k)
+		}
+	case DupCollect:
+		f := dl[0]
+		l := &OcNode{Kind: NodeList, Name: f.Name, Index: -1, Item: f.Item, Last: f.Last,
+			Up: f.Up, top: len(dl)}
+		for x, k := range dl {
+			if x > 0 {
+				k.Up.drop(k)
+			}
+			k.Up, k.Index, k.Name = l, x, ""
+			l.Kids = append(l.Kids, k)
+		}
+		for x := range l.Up.Kids {
+			if l.Up.Kids[x] == f {
+				l.Up.Kids[x] = l
+			}
+		}
+	default:
+		return t.errAt(dl[1].Item, "duplicate key "+c+", given also at line "+
+			lineStr(t.Oc.ItemLine(dl[0].Item)))
+	}
+	return nil
+}
+
+// func hasNode tells whether n is in the nl.
+func hasNode(nl []*OcNode, n *OcNode) bool {
+	for _, k := range nl {
+		if k == n {
+			return true
+		}
+	}
+	return false
+}
+
+// func walkNamed calls f for every named member of n, looking through
+// groups.
+func (n *OcNode) walkNamed(f func(*OcNode)) {
+	for _, k := range n.Kids {
+		switch {
+		case k.Kind == NodeGroup:
+			k.walkNamed(f)
+		case k.Index < 0:
+			f(k)
+		}
+	}
+}
+
+// func drop removes k from members of n.
+func (n *OcNode) drop(k *OcNode) {
+	for x := range n.Kids {
+		if n.Kids[x] == k {
+			n.Kids = append(n.Kids[:x], n.Kids[x+1:]...)
+			return
+		}
+	}
+}
+
+// func canonName squeezes inner blank runs of a name to a single space
+// and removes blanks around dots. Leading blanks (given after a ' quote)
+// are kept.
+func canonName(s string) string {
+	lead := len(s) - len(strings.TrimLeft(s, " \t"))
+	b := []byte(s[:lead])
+	blank := false
+	for i := lead; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			blank = true
+			continue
+		case blank && c != '.' && b[len(b)-1] != '.':
+			b = append(b, ' ')
+		}
+		blank = false
+		b = append(b, s[i])
+	}
+	return string(b)
+}
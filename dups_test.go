@@ -0,0 +1,137 @@
+package octok
+
+import (
+	"strings"
+	"testing"
+)
+
+const tDups string = ` ^ Section : ---
+     a key : first
+   'a  key : second
+       a.b : dotted
+         a { :
+           b : nested
+           } :
+       one : only
+         ( : ^.
+     a key : third
+         ) :
+`
+
+func TestDupKeys(t *testing.T) {
+	var oc OcFlat
+	for _, te := range []struct {
+		dups DupPolicy
+		vals string // leaf values left
+	}{
+		{DupFirst, "first|dotted|only"},
+		{DupLast, "nested|only|third"},
+		{DupCollect, "first|second|third|dotted|nested|only"},
+	} {
+		Reset(&oc, []byte(tDups), true)
+		oc.LintFull = true
+		if ok := oc.Tokenize(); !ok {
+			t.Fatalf("Bad. Dups test config should parse but it did not!")
+		}
+		tr := OcTree{Oc: &oc, Dups: te.dups}
+		if err := tr.Build(); err != nil {
+			t.Errorf("Bad. Dups tree (policy %d) should build but it did not: %s", te.dups, err)
+			continue
+		}
+		var vals []string
+		tr.Walk(func(n *OcNode, path string, depth int, enter bool) WalkAct {
+			if enter && n.Kind == NodeLeaf {
+				vals = append(vals, oc.RawValueStr(n.Item))
+			}
+			return WalkOn
+		})
+		if v := strings.Join(vals, "|"); v != te.vals {
+			t.Errorf("Bad. Policy %d should leave »%s«, left »%s«", te.dups, te.vals, v)
+		}
+		var lines []uint32
+		for _, l := range oc.Lapses {
+			if l.What == LintDupKey {
+				lines = append(lines, l.Line)
+			}
+		}
+		if oc.LapsesFound != 5 || len(lines) != 5 || lines[0] != 2 || lines[2] != 10 || lines[4] != 6 {
+			t.Errorf("Bad. Every duplicate should be linted, got %v", oc.Lapses)
+		}
+	}
+	tr := OcTree{Oc: &oc, Dups: DupCollect}
+	Reset(&oc, []byte(tDups), false)
+	oc.Tokenize()
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Dups tree should build but it did not: %s", err)
+	}
+	if n, err := tr.Lookup("/Section/a key"); err != nil || n.Kind != NodeList || len(n.Dense()) != 3 {
+		t.Errorf("Bad. Duplicates should be collected into a list (%v)", err)
+	} else if v := oc.RawValueStr(n.Dense()[2].Item); v != "third" {
+		t.Errorf("Bad. Collected [2] should be third, is %s", v)
+	}
+	tr.Dups = DupError
+	Reset(&oc, []byte(tDups), false)
+	oc.Tokenize()
+	if err := tr.Build(); err == nil || err.(*OcError).Line != 3 {
+		t.Errorf("Bad. Duplicate key should err at line 3, got: %v", err)
+	}
+	for _, te := range []struct{ in, out string }{
+		{"a  key", "a key"},
+		{"a . b", "a.b"},
+		{" spkey  x", " spkey x"},
+		{"a\t. b .c", "a.b.c"},
+	} {
+		if c := canonName(te.in); c != te.out {
+			t.Errorf("Bad. Canonical of »%s« should be »%s«, is »%s«", te.in, te.out, c)
+		}
+	}
+}
+
+func TestDupKeysMixed(t *testing.T) {
+	var oc OcFlat
+	for _, te := range []struct {
+		conf string
+		dups DupPolicy
+		vals string // leaf values left
+	}{
+		{"a { :\n b : 2\n } :\na.b : 1\n", DupLast, "1"},
+		{"a { :\n b : 2\n } :\na.b : 1\n", DupFirst, "2"},
+		{"a { :\n b : 2\n } :\na.b : 1\n", DupCollect, "2|1"},
+		{"a.b : 1\na.b : 2\na { :\n b : 3\n } :\n", DupLast, "3"},
+		{"a.b : 1\na.b : 2\na { :\n b : 3\n } :\n", DupFirst, "1"},
+		{"a.b : 1\na.b : 2\na { :\n b : 3\n } :\n", DupCollect, "1|2|3"},
+	} {
+		Reset(&oc, []byte(te.conf), false)
+		if !oc.Tokenize() {
+			t.Fatalf("Bad. »%s« should parse but it did not!", te.conf)
+		}
+		tr := OcTree{Oc: &oc, Dups: te.dups}
+		if err := tr.Build(); err != nil {
+			t.Errorf("Bad. »%s« (policy %d) should build but it did not: %s", te.conf, te.dups, err)
+			continue
+		}
+		var vals []string
+		tr.Walk(func(n *OcNode, path string, depth int, enter bool) WalkAct {
+			if enter && n.Kind == NodeLeaf {
+				vals = append(vals, oc.RawValueStr(n.Item))
+			}
+			return WalkOn
+		})
+		if v := strings.Join(vals, "|"); v != te.vals {
+			t.Errorf("Bad. »%s« policy %d should leave »%s«, left »%s«", te.conf, te.dups, te.vals, v)
+		}
+	}
+}
+
+func TestDupKeysManyDots(t *testing.T) {
+	var oc OcFlat
+	conf := "x { :\n q { :\n  q : v\n } :\n} :\nx" + strings.Repeat(".q", 44) + " : w\n"
+	Reset(&oc, []byte(conf), false)
+	if !oc.Tokenize() {
+		t.Fatalf("Bad. Many dots config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Errorf("Bad. Many dots key is not a duplicate, got: %s", err)
+	}
+}
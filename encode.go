@@ -0,0 +1,437 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the OCONF text of v, a struct or a map (or a pointer
+// to one). Fields and keys are written in the form Unmarshal reads:
+//
+// Structs given at the config top or within a section are written as
+// "^ Name :" sections, with their sections after their other members.
+// Other structs, and maps of string keys, are written as "name { :"
+// dicts; slices, arrays and maps of integer keys as "name [ :" lists.
+// Struct fields are named as their `oconf:"name"` tag tells, or by their
+// own name. Fields tagged `oconf:"-"` and not exported ones are skipped,
+// so are fields tagged `oconf:",omitempty"` if their value is empty, and
+// nil pointers, maps and slices. Fields of embedded structs are written
+// as if they were given in the outer struct. Map keys are sorted.
+//
+// Values are written untyped, with pragmas added as needed to have them
+// tokenize back exactly as given: |. for trailing blanks, '. for a value
+// with a " //" remark mark or ending with what could be a pragma, \. (and
+// Go escapes) for control characters. Multiline values are written as :==
+// raw blocks. Types that implement Marshaler or the encoding.TextMarshaler
+// give their values on their own, see Marshaler. The time.Duration,
+// time.Time, net.IP, net.IPNet and url.URL values are written in the form
+// Unmarshal reads, times as RFC3339 ones. Names that would be taken for an
+// index, a bracket or a comment are quoted with a '. Names that would not
+// read back, eg. with a control character or a colon next to a blank, are
+// errors; so are section names that start with a blank or a colon, or end
+// with a bracket. Nil list elements are written as "-." typed empty
+// values, to keep the indexes.
+func Marshal(v interface{}) ([]byte, error) {
+	var e encoder
+	rv := indirect(reflect.ValueOf(v))
+	if k := rv.Kind(); k != reflect.Struct && k != reflect.Map {
+		return nil, errors.New("octok: Marshal needs a struct or a map")
+	}
+	if err := e.section(rv, 0); err != nil {
+		return nil, err
+	}
+	return e.b, nil
+}
+
+// encoder keeps state of a Marshal run.
+type encoder struct {
+	b []byte // output
+}
+
+// member is a struct field, map entry or slice element to be written.
+type member struct {
+	name string        // name, empty for an ORD member
+	idx  int           // explicit index, -1 if none
+	v    reflect.Value // value
+}
+
+// func indirect follows pointers and interfaces of v down to a value.
+// Nil one gives an invalid Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// func section writes members of v at the section depth.
+func (e *encoder) section(v reflect.Value, depth int) error {
+	ms, err := membersOf(v)
+	if err != nil {
+		return err
+	}
+	var subs []member
+	for _, m := range ms {
+		if v := indirect(m.v); v.Kind() == reflect.Struct && m.idx < 0 && !isCustom(v) {
+			subs = append(subs, m)
+		} else if err = e.member(m, ""); err != nil {
+			return err
+		}
+	}
+	for _, m := range subs {
+		if nameErr(m.name) || m.name[0] == ' ' || m.name[0] == '\t' || m.name[0] == ':' ||
+			isStructure(m.name[len(m.name)-1]) {
+			return errors.New("octok: can not write section " + strconv.Quote(m.name))
+		}
+		e.b = append(e.b, strings.Repeat(string(SectLead), depth+1)+" "+m.name+" :\n"...)
+		if err = e.section(indirect(m.v), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func membersOf returns members of the v struct or map.
+func membersOf(v reflect.Value) (ms []member, err error) {
+	if v.Kind() == reflect.Struct {
+		return fieldsOf(v, ms), nil
+	}
+	keys := v.MapKeys()
+	switch v.Type().Key().Kind() {
+	case reflect.String:
+		sort.Slice(keys, func(a, b int) bool { return keys[a].String() < keys[b].String() })
+		for _, k := range keys {
+			if k.String() == "" {
+				return nil, errors.New("octok: can not write an empty map key")
+			}
+			ms = append(ms, member{name: k.String(), idx: -1, v: v.MapIndex(k)})
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sort.Slice(keys, func(a, b int) bool { return keys[a].Int() < keys[b].Int() })
+		for _, k := range keys {
+			if k.Int() < 0 || k.Int() > maxIndex {
+				return nil, errors.New("octok: map key " + strconv.FormatInt(k.Int(), 10) + " is not an index")
+			}
+			ms = append(ms, member{idx: int(k.Int()), v: v.MapIndex(k)})
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		sort.Slice(keys, func(a, b int) bool { return keys[a].Uint() < keys[b].Uint() })
+		for _, k := range keys {
+			if k.Uint() > maxIndex {
+				return nil, errors.New("octok: map key " + strconv.FormatUint(k.Uint(), 10) + " is not an index")
+			}
+			ms = append(ms, member{idx: int(k.Uint()), v: v.MapIndex(k)})
+		}
+	default:
+		return nil, errors.New("octok: can not write " + v.Type().String())
+	}
+	return
+}
+
+// func fieldsOf appends fields of the v struct to ms.
+func fieldsOf(v reflect.Value, ms []member) []member {
+	t := v.Type()
+	for x := 0; x < t.NumField(); x++ {
+		sf := t.Field(x)
+		name, opts := sf.Tag.Get("oconf"), ""
+		if c := strings.IndexByte(name, ','); c >= 0 {
+			name, opts = name[:c], name[c:]
+		}
+		fv := v.Field(x)
+		switch {
+		case name == "-":
+		case sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct:
+			ms = fieldsOf(fv, ms)
+		case sf.PkgPath != "":
+		case isNil(fv), strings.Contains(opts, ",omitempty") && isEmpty(fv):
+		default:
+			if name == "" {
+				name = sf.Name
+			}
+			ms = append(ms, member{name: name, idx: -1, v: fv})
+		}
+	}
+	return ms
+}
+
+// func isNil tells whether v is a nil pointer, interface, map or slice.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// func isEmpty tells whether v is false, 0, an empty string or a nil or
+// empty container.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	}
+	return isNil(v)
+}
+
+// func member writes m, indented with ind, as a value or a bracket.
+func (e *encoder) member(m member, ind string) error {
+	head := ind
+	switch {
+	case m.idx >= 0:
+		head += strconv.Itoa(m.idx) + " "
+	case m.name != "":
+		n, err := quoteName(m.name)
+		if err != nil {
+			return err
+		}
+		head += n + " "
+	}
+	v := indirect(m.v)
+	if isCustom(v) {
+		val, pr, err := marshalCustom(v)
+		if err != nil {
+			return err
+		}
+		e.value(head, string(val), pr)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		e.b = append(e.b, head+": -.\n"...)
+		return nil
+	case reflect.Struct, reflect.Map:
+		ms, err := membersOf(v)
+		if err != nil {
+			return err
+		}
+		cl := "} :\n"
+		if v.Kind() == reflect.Map && v.Type().Key().Kind() != reflect.String {
+			e.b, cl = append(e.b, head+"[ :\n"...), "] :\n"
+		} else {
+			e.b = append(e.b, head+"{ :\n"...)
+		}
+		for _, mm := range ms {
+			if err = e.member(mm, ind+"  "); err != nil {
+				return err
+			}
+		}
+		e.b = append(e.b, ind+cl...)
+		return nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break // []byte goes as a string
+		}
+		e.b = append(e.b, head+"[ :\n"...)
+		for x := 0; x < v.Len(); x++ {
+			if err := e.member(member{idx: -1, v: v.Index(x)}, ind+"  "); err != nil {
+				return err
+			}
+		}
+		e.b = append(e.b, ind+"] :\n"...)
+		return nil
+	}
+	s, err := scalarText(v)
+	if err != nil {
+		return err
+	}
+	e.value(head, s, "")
+	return nil
+}
+
+// func isCustom tells whether v implements Marshaler or the
+// encoding.TextMarshaler, or is of one of the stdTypes.
+func isCustom(v reflect.Value) bool {
+	if !v.IsValid() || !v.CanInterface() {
+		return false
+	}
+	if stdTypes[v.Type()] {
+		return true
+	}
+	pt := reflect.PtrTo(v.Type())
+	return pt.Implements(marshalerType) || pt.Implements(textMarshalerType)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// func marshalCustom asks the custom v for its value and pragmas.
+func marshalCustom(v reflect.Value) (val []byte, pr string, err error) {
+	if stdTypes[v.Type()] {
+		return []byte(stdText(v)), "", nil
+	}
+	pv := reflect.New(v.Type())
+	pv.Elem().Set(v)
+	switch m := pv.Interface().(type) {
+	case Marshaler:
+		return m.MarshalOconf()
+	case encoding.TextMarshaler:
+		val, err = m.MarshalText()
+	}
+	return
+}
+
+// func scalarText returns the text of a bool, number, string or []byte.
+func scalarText(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return string(b), nil
+		}
+	}
+	return "", errors.New("octok: can not write " + v.Type().String())
+}
+
+// func nameErr tells whether s can not be written as a name, quoted or
+// not: it is empty, ends with a blank or a colon, has a control character
+// (but a tab) or a colon next to a blank, that would be taken for the
+// separator.
+func nameErr(s string) bool {
+	l := len(s)
+	if l == 0 || s[l-1] == ' ' || s[l-1] == '\t' || s[l-1] == ':' ||
+		strings.Contains(s, ": ") || strings.Contains(s, ":\t") ||
+		strings.Contains(s, " :") || strings.Contains(s, "\t:") {
+		return true
+	}
+	for i := 0; i < l; i++ {
+		if c := s[i]; c < ' ' && c != '\t' || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// func quoteName returns the name, quoted if it would not tokenize back
+// as an ordinary name.
+func quoteName(s string) (string, error) {
+	l := len(s)
+	switch {
+	case nameErr(s):
+		return "", errors.New("octok: can not write name " + strconv.Quote(s))
+	case s[0] <= '9', s[0] == ':', isStructure(s[0]), isStructure(s[l-1]):
+		return "'" + s, nil
+	}
+	return s, nil
+}
+
+// func value writes the head then a value line (or block) of s, with the
+// cp custom pragmas added.
+func (e *encoder) value(head, s, cp string) {
+	if s == "" {
+		if cp != "" {
+			cp = " " + cp + "."
+		}
+		e.b = append(e.b, head+":"+cp+"\n"...)
+		return
+	}
+	esc, nl := false, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\n':
+			nl = true
+		case c == '\r', c == '\t':
+		case c < 0x20, c == 0x7f:
+			esc = true
+		}
+	}
+	if nl && !esc && cp == "" {
+		bnd := "==RawEnd"
+		for k := 0; strings.Contains(s, bnd); k++ {
+			bnd = "==RawE" + strconv.Itoa(k/10) + strconv.Itoa(k%10)
+		}
+		if bnd != "==RawEnd" {
+			head += ":== " + bnd + "\n"
+		} else {
+			head += ":==\n"
+		}
+		e.b = append(e.b, head+s+bnd+"\n"...)
+		return
+	}
+	pr := cp
+	if esc || nl || strings.ContainsRune(s, '\r') {
+		s, pr = escapeValue(s), `\`+cp
+	}
+	l := len(s)
+	switch {
+	case s[l-1] == ' ' || s[l-1] == '\t':
+		pr = "|" + pr
+	case strings.HasPrefix(s, "//") || strings.Contains(s, " //") || strings.Contains(s, "\t//"),
+		s[l-1] == '.' || s[l-1] == '/':
+		pr = " '" + pr
+	case pr != "":
+		pr = " " + pr
+	}
+	if pr != "" {
+		pr += "."
+	}
+	e.b = append(e.b, head+": "+s+pr+"\n"...)
+}
+
+// func escapeValue Go escapes backslashes and control characters of s,
+// as AppendUnesc reads them back.
+func escapeValue(s string) string {
+	const hex = "0123456789abcdef"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				b.WriteString(`\x` + hex[c>>4:c>>4+1] + hex[c&15:c&15+1])
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package octok
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tEncInner struct {
+	Deep string
+}
+
+type tEncSect struct {
+	Host  string
+	Ports []uint16
+	Inner tEncInner
+	Dict  map[string]int
+	List  []tEncInner
+	Ptrs  []*int
+}
+
+type tEncConf struct {
+	tEmbed
+	Name   string
+	Ratio  float64
+	Empty  string `oconf:",omitempty"`
+	Raw    []byte
+	Idx    map[int]string
+	Server tEncSect `oconf:"The Server"`
+	Nil    *tEncSect
+	skip   int
+}
+
+func TestMarshal(t *testing.T) {
+	one := 1
+	in := tEncConf{
+		tEmbed: tEmbed{Debug: true},
+		Name:   "demo",
+		Ratio:  0.5,
+		Raw:    []byte("two\nlines"),
+		Idx:    map[int]string{3: "three", 1: "one"},
+		Server: tEncSect{
+			Host:  "example.com",
+			Ports: []uint16{80, 443},
+			Inner: tEncInner{Deep: "down"},
+			Dict:  map[string]int{"b": 2, "a": 1},
+			List:  []tEncInner{{"x"}, {"y"}},
+			Ptrs:  []*int{nil, &one},
+		},
+	}
+	out, err := Marshal(&in)
+	if err != nil {
+		t.Fatalf("Bad. Test struct should marshal but it did not: %s", err)
+	}
+	exp := `Debug : true
+Name : demo
+Ratio : 0.5
+Raw :==
+two
+lines==RawEnd
+Idx [ :
+  1 : one
+  3 : three
+] :
+^ The Server :
+Host : example.com
+Ports [ :
+  : 80
+  : 443
+] :
+Dict { :
+  a : 1
+  b : 2
+} :
+List [ :
+  { :
+    Deep : x
+  } :
+  { :
+    Deep : y
+  } :
+] :
+Ptrs [ :
+  : -.
+  : 1
+] :
+^^ Inner :
+Deep : down
+`
+	if string(out) != exp {
+		t.Errorf("Bad marshal.\nExpected:\n%s\n     Got:\n%s", exp, out)
+	}
+	var back tEncConf
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatalf("Bad. Marshaled text should unmarshal but it did not: %s", err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Errorf("Bad. Round trip changed the value.\nExpected: %+v\n     Got: %+v", in, back)
+	}
+}
+
+func TestMarshalValues(t *testing.T) {
+	in := map[string]string{
+		"plain":        "value",
+		" spkey":       "leading space key",
+		"33":           "string key",
+		"'q":           "quoted",
+		"brace {":      "not a dict",
+		"ctl":          "bell\a and \\ back",
+		"blanks":       "  lead and trail  ",
+		"remark":       "a // b",
+		"start":        "// x",
+		"pragma":       "looks +.",
+		"meta":         "tagged @one;/",
+		"tabs":         "in\tside\t",
+		"crlf":         "one\r\ntwo",
+		"raw":          "line\n==RawEnd\n",
+		"dot":          "end.",
+		"empty":        "",
+		"double colon": ":: x",
+		"eqeq":         "== RawBound",
+		"ctl and nl":   "a\nb\x01",
+		"unicode":      "Юрий 键 ",
+	}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Bad. Values should marshal but they did not: %s", err)
+	}
+	var oc OcFlat
+	oc.Inbuf = out
+	oc.LintFull = true
+	if !oc.Tokenize() || oc.LapsesFound != 0 {
+		t.Errorf("Bad. Marshaled values should tokenize clean. Got %v %v\n%s", oc.BadLint, oc.Lapses, out)
+	}
+	back := make(map[string]string)
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatalf("Bad. Marshaled values should unmarshal but they did not: %s\n%s", err, out)
+	}
+	for k, v := range in {
+		if back[k] != v {
+			t.Errorf("Bad. %q should read back as %q, got %q", k, v, back[k])
+		}
+	}
+	if len(back) != len(in) {
+		t.Errorf("Bad. Expected %d keys back, got %d:\n%s", len(in), len(back), out)
+	}
+	for _, bad := range []interface{}{
+		42,
+		map[string]string{"a: b": "x"},
+		map[string]string{"": "x"},
+		map[string]chan int{"c": nil},
+		map[string]interface{}{"f": func() {}},
+		map[int]string{-1: "x"},
+	} {
+		if _, err := Marshal(bad); err == nil || !strings.HasPrefix(err.Error(), "octok:") {
+			t.Errorf("Bad. %#v should not marshal (%v)", bad, err)
+		}
+	}
+}
+
+func TestMarshalNames(t *testing.T) {
+	const chars = "a :.\\'/[(<^@1|=-\t\x01\x7f"
+	var names []string
+	for x := range chars {
+		for y := range chars {
+			for z := range chars {
+				names = append(names, chars[x:x+1]+chars[y:y+1]+chars[z:z+1])
+			}
+		}
+	}
+	for _, n := range names {
+		keys := map[string]string{n: "v"}
+		if out, err := Marshal(keys); err == nil {
+			var back map[string]string
+			if err = Unmarshal(out, &back); err != nil || !reflect.DeepEqual(back, keys) {
+				t.Errorf("Bad. Key %q should read back or not marshal, got %q (%v):\n%s", n, back, err, out)
+			}
+		}
+		sects := map[string]tEncInner{n: {"v"}}
+		if out, err := Marshal(sects); err == nil {
+			var back map[string]tEncInner
+			if err = Unmarshal(out, &back); err != nil || !reflect.DeepEqual(back, sects) {
+				t.Errorf("Bad. Section %q should read back or not marshal, got %q (%v):\n%s", n, back, err, out)
+			}
+		}
+	}
+	for _, bad := range []interface{}{
+		map[string]tEncInner{"a : b": {"v"}},
+		map[string]tEncInner{":a": {"v"}},
+		map[string]tEncInner{"a(": {"v"}},
+		map[string]string{"\\ :.": "v"},
+		map[string]string{"a :.": "v"},
+		map[string]string{"a\x01": "v"},
+		map[string]string{"a\x7fb": "v"},
+		map[string]tEncInner{"a\x1b": {"v"}},
+	} {
+		if _, err := Marshal(bad); err == nil {
+			t.Errorf("Bad. %#v should not marshal but it did!", bad)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import "bytes"
+
+// func bracketOf returns the structure bracket the i-th Item opens or
+// closes, or 0 if Item is not a bracket one. Opening bracket ends the
+// name, like in "dictname {" or "(". Closing one must be the whole name.
+func (oc *OcFlat) bracketOf(i int) byte {
+	it := &oc.Items[i]
+	if it.Fl&IsSpec == 0 || it.Ne == it.Ns {
+		return 0
+	}
+	switch c := oc.Inbuf[it.Ne-1]; c {
+	case '(', '[', '{', '<':
+		return c
+	case ')', ']', '}', '>':
+		if it.Ne-it.Ns == 1 {
+			return c
+		}
+	}
+	return 0
+}
+
+// func isPlainOrd tells whether the i-th Item is an ORD one without an
+// explicit index, ie. one that may continue a join chain.
+func (oc *OcFlat) isPlainOrd(i int) bool {
+	return i < len(oc.Items) && oc.Items[i].Fl&(IsOrd|IsIndex|IsSpec) == IsOrd
+}
+
+// ExpandGroups merges value pragmas given to a group opener "( :" into
+// every Item of the group, up to the matching ") :" closer. Eligible for
+// grouping are metas and the + \ ` ^ pragmas. The | ' % pragmas, and type
+// characters too, can not be grouped - ExpandGroups errs if a group opener
+// has any of them. Merged pragmas add to the Item's own ones, except
+// carets: Item that has its own keeps them. Join pragma is merged only
+// into Items that are followed by a plain ORD Item, so the last line of
+// a group never joins with the closer. Group metas are not copied but
+// remembered: see GroupOf and MetaList.
+//
+// Groups may nest. Inner group Items get pragmas of all enclosing groups.
+// Unbalanced group brackets are reported with *OcError.
+func (oc *OcFlat) ExpandGroups() error {
+	type grpSet struct {
+		item int
+		fl   ItemFL
+		tc   OcItemTc
+	}
+	var stack []grpSet
+	oc.groups = nil
+	for i := range oc.Items {
+		br := oc.bracketOf(i)
+		if br == '(' || br == ')' {
+			if oc.groups == nil {
+				oc.groups = make([]int32, len(oc.Items))
+				for k := range oc.groups {
+					oc.groups[k] = -1
+				}
+			}
+		}
+		if br == ')' {
+			if len(stack) == 0 {
+				return &OcError{Pos: oc.Items[i].Ns, Line: oc.ItemLine(i),
+					Msg: "group closer has no opener"}
+			}
+			stack = stack[:len(stack)-1]
+		}
+		var top grpSet
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+			oc.groups[i] = int32(top.item)
+		}
+		it := &oc.Items[i]
+		switch {
+		case br == '(':
+			if p := oc.Pragmas(i); bytes.ContainsAny(p, "|'%") ||
+				(it.Tc != 0 && it.Tc < TcHasCarets) {
+				return &OcError{Pos: it.Ps, Line: oc.ItemLine(i),
+					Msg: "pragmas | ' % and types can not be grouped"}
+			}
+			g := grpSet{item: i, fl: it.Fl&(NextCont|Unescape|Backtick) | top.fl, tc: it.Tc}
+			if g.tc == 0 {
+				g.tc = top.tc
+			}
+			stack = append(stack, g)
+		case br != 0 || len(stack) == 0: // other structure, or not in group
+		default:
+			it.Fl |= top.fl & (Unescape | Backtick)
+			if top.fl&NextCont != 0 && oc.isPlainOrd(i+1) {
+				it.Fl |= NextCont
+			}
+			switch {
+			case top.tc == 0, it.Tc&TcHasCarets != 0:
+			case it.Tc == 0:
+				it.Tc = top.tc
+			default: // typed Item in a ^ group
+				it.Tc = TcTypeAndNL
+			}
+		}
+	}
+	if len(stack) > 0 {
+		i := stack[len(stack)-1].item
+		return &OcError{Pos: oc.Items[i].Ns, Line: oc.ItemLine(i),
+			Msg: "group opened here is not closed"}
+	}
+	return nil
+}
+
+// GroupOf returns the index of the innermost group opener the i-th Item
+// belongs to, or -1 if it is not within a group. It is valid after the
+// ExpandGroups call.
+func (oc *OcFlat) GroupOf(i int) int {
+	if oc.groups == nil {
+		return -1
+	}
+	return int(oc.groups[i])
+}
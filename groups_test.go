@@ -0,0 +1,61 @@
+package octok
+
+import "testing"
+
+const tGroups string = ` ^^ PGroups : ---------------------
+    ( : group pragma ^+.
+      : many lines may come here
+      :  that keep indent line but
+      :  sometimes need to be disa
+      : mbiguated for // or ?.  '.
+      ( : inner \.
+        : \tend
+      ) :
+    ) : group ends
+  after : v
+`
+
+func TestExpandGroups(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tGroups)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Groups test config should parse but it did not!")
+	}
+	if err := oc.ExpandGroups(); err != nil {
+		t.Fatalf("Bad. Groups should expand but they did not: %s", err)
+	}
+	lv, err := oc.Logical(2)
+	if exp := "many lines may come here\n that keep indent line but\n" +
+		" sometimes need to be disa\nmbiguated for // or ?.\n"; err != nil || string(lv.Val) != exp {
+		t.Errorf("Bad grouped value. Expected »%q« got »%q« (%v)", exp, lv.Val, err)
+	}
+	if lv.Last != 5 {
+		t.Errorf("Bad. Join should stop at the last line before inner group, stopped at %d", lv.Last)
+	}
+	if v, err := oc.Value(7); err != nil || string(v) != "\tend\n" {
+		t.Errorf("Bad inner group value. Got »%q« (%v)", v, err)
+	}
+	for i, g := range []int{-1, -1, 1, 1, 1, 1, 1, 6, 1, -1, -1} {
+		if oc.GroupOf(i) != g {
+			t.Errorf("Bad. Item %d should be in group %d, it is in %d", i, g, oc.GroupOf(i))
+		}
+	}
+	if err := oc.ExpandGroups(); err != nil || oc.Items[2].Tc != TcHasCarets|1 {
+		t.Errorf("Bad. Second ExpandGroups should change nothing (%v)", err)
+	}
+	for _, bad := range []string{
+		"( : |.\n : v\n) :\n",
+		"( : '.\n : v\n) :\n",
+		"( : %.\n : v\n) :\n",
+		"( : #.\n : v\n) :\n",
+		"( :\n : v\n",
+		" : v\n) :\n",
+	} {
+		Reset(&oc, []byte(bad), false)
+		if ok := oc.Tokenize(); !ok {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad)
+		} else if err := oc.ExpandGroups(); err == nil {
+			t.Errorf("Bad. »%s« should not expand but it did!", bad)
+		}
+	}
+}
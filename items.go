@@ -0,0 +1,138 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+// Accessors below give views into the Inbuf for the i-th OcItem. They
+// encode the OcItem position fields semantics once, so callers need not
+// to. Returned slices share the Inbuf backing array but have their
+// capacity clipped, so an append to them will not overwrite the buffer.
+// As with plain slice indexing, an out of range i panics.
+
+// Name returns the name of the i-th Item. Name of an ORD item is empty.
+// Name forced with a quote (like '^ escape) starts right after the quote.
+func (oc *OcFlat) Name(i int) []byte {
+	it := &oc.Items[i]
+	return oc.Inbuf[it.Ns:it.Ne:it.Ne]
+}
+
+// RawValue returns the value of the i-th Item exactly as it was written.
+// Trailing blanks kept by the |. guard are included, pragmas and metas
+// are not. For a :== item RawValue returns the whole raw block.
+func (oc *OcFlat) RawValue(i int) []byte {
+	it := &oc.Items[i]
+	return oc.Inbuf[it.Vs:it.Ve:it.Ve]
+}
+
+// Pragmas returns the value pragma characters of the i-th Item, without
+// metas and without the pragma ending dot. For a :== item these are the
+// pragmas given on its header line.
+func (oc *OcFlat) Pragmas(i int) []byte {
+	it := &oc.Items[i]
+	e := it.Ms
+	if e == it.Pe && e > it.Ps { // no metas, so the dot is here
+		e--
+	}
+	return oc.Inbuf[it.Ps:e:e]
+}
+
+// Metas returns the metas span of the i-th Item, without the pragma
+// ending dot. Chained metas are returned together. See MetaList.
+func (oc *OcFlat) Metas(i int) []byte {
+	it := &oc.Items[i]
+	e := it.Ms
+	if it.Ms < it.Pe {
+		e = it.Pe - 1
+	}
+	return oc.Inbuf[it.Ms:e:e]
+}
+
+// IsRaw tells whether the i-th Item is a :== raw multiline one.
+// Raw block always starts past the header line, the only one where
+// pragmas might be.
+func (oc *OcFlat) IsRaw(i int) bool {
+	it := &oc.Items[i]
+	return it.Vs > it.Pe
+}
+
+// NameStr returns Name(i) as a string.
+func (oc *OcFlat) NameStr(i int) string { return string(oc.Name(i)) }
+
+// RawValueStr returns RawValue(i) as a string.
+func (oc *OcFlat) RawValueStr(i int) string { return string(oc.RawValue(i)) }
+
+// PragmasStr returns Pragmas(i) as a string.
+func (oc *OcFlat) PragmasStr(i int) string { return string(oc.Pragmas(i)) }
+
+// MetasStr returns Metas(i) as a string.
+func (oc *OcFlat) MetasStr(i int) string { return string(oc.Metas(i)) }
+
+// NameParts decodes the bit packed Np field of the i-th Item and returns
+// the parts of its name, trimmed of separating dots and blanks. Parts
+// that are empty after trimming (like the middle one of "a..b") are not
+// returned. Dot tells whether a dot has separated the part from the
+// previous one. Returned more flag is set if the last part still holds
+// separators that Tokenize was not able to register (the LintKeyParts
+// case), ie. the name has more parts than Np can describe.
+func (oc *OcFlat) NameParts(i int) (parts []OcPart, more bool) {
+	it := &oc.Items[i]
+	b := oc.Inbuf
+	np := it.Np
+	var n uint // № of offsets recorded. Sentinel 1 precedes the first.
+	switch {
+	case np&NpOverParts != 0:
+		n = 3
+	case np>>10 == 1:
+		n = 2
+	case np>>5 == 1:
+		n = 1
+	}
+	parts = make([]OcPart, 0, n+1)
+	var dot bool
+	s := it.Ns
+	quoted := s > 0 && b[s-1] == '\'' // blanks after the quote are the name
+	for k := uint(0); k <= n; k++ {
+		e := it.Ne
+		if k < n {
+			e = it.Ns + uint32(np>>(5*(n-1-k))&0x1f)
+		}
+		p := OcPart{S: s, E: e, Dot: dot}
+		dot = false
+		for p.S < p.E && (b[p.S] == ' ' || b[p.S] == '\t') && !quoted {
+			p.S++
+		}
+		if k < n { // strip separator
+			for p.E > p.S && (b[p.E-1] == ' ' || b[p.E-1] == '\t') {
+				p.E--
+			}
+			if p.E > p.S && b[p.E-1] == '.' {
+				p.E--
+				dot = true
+			}
+			for p.E > p.S && (b[p.E-1] == ' ' || b[p.E-1] == '\t') {
+				p.E--
+			}
+		}
+		if p.S < p.E {
+			parts = append(parts, p)
+		} else {
+			dot = dot || p.Dot // keep dot of the skipped one
+		}
+		s, quoted = e, false
+	}
+	if len(parts) > 0 {
+		p := parts[len(parts)-1]
+		j := p.S
+		for j < p.E && (b[j] == ' ' || b[j] == '\t') { // quoted lead
+			j++
+		}
+		for ; j < p.E; j++ {
+			if c := b[j]; c == '.' || c == ' ' || c == '\t' {
+				more = true
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package octok
+
+import "testing"
+
+const tAccess string = ` ^ Section : ----- lead --- //  section
+ '^ escape : not a section lead
+    spaced :  val & spaces     |.   // guarded
+    noComm : hello // there    '.   // disa
+   withCTL : Use\v vtab \.
+      ann  : v +@ann;=x/.
+      lone : @ann;.
+      nv   : ^.
+      ne   :
+           : ord value
+  mtx :== xHereRaw
+    line one
+  xHereRaw
+`
+
+type tAccessItem struct {
+	name, value, pragmas, metas string
+	raw                         bool
+}
+
+var tAccessTable = []tAccessItem{
+	{"^ Section", "----- lead ---", "", "", false},
+	{"^ escape", "not a section lead", "", "", false},
+	{"spaced", " val & spaces     ", "|", "", false},
+	{"noComm", "hello // there", "'", "", false},
+	{"withCTL", `Use\v vtab`, `\`, "", false},
+	{"ann", "v", "+", "@ann;=x/", false},
+	{"lone", "", "", "@ann;", false},
+	{"nv", "", "^", "", false},
+	{"ne", "", "", "", false},
+	{"", "ord value", "", "", false},
+	{"mtx", "    line one\n  ", "", "", true},
+}
+
+func TestAccessors(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tAccess)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Accessors test config should parse but it did not! [%s]", oc.BadLint.What.StrAll())
+	}
+	if len(oc.Items) != len(tAccessTable) {
+		t.Fatalf("Bad. Expected %d items, got %d!", len(tAccessTable), len(oc.Items))
+	}
+	for i, e := range tAccessTable {
+		if s := oc.NameStr(i); s != e.name {
+			t.Errorf("Bad Name of item %d. Expected »%s« got »%s«", i, e.name, s)
+		}
+		if s := oc.RawValueStr(i); s != e.value {
+			t.Errorf("Bad RawValue of item %d. Expected »%s« got »%s«", i, e.value, s)
+		}
+		if s := oc.PragmasStr(i); s != e.pragmas {
+			t.Errorf("Bad Pragmas of item %d. Expected »%s« got »%s«", i, e.pragmas, s)
+		}
+		if s := oc.MetasStr(i); s != e.metas {
+			t.Errorf("Bad Metas of item %d. Expected »%s« got »%s«", i, e.metas, s)
+		}
+		if r := oc.IsRaw(i); r != e.raw {
+			t.Errorf("Bad IsRaw of item %d. Expected %v got %v", i, e.raw, r)
+		}
+	}
+	n := oc.Name(0)
+	_ = append(n, 'X')
+	if oc.Inbuf[oc.Items[0].Ne] == 'X' {
+		t.Errorf("Bad. Append to accessor result overwrote the Inbuf!")
+	}
+}
+
+func TestNameParts(t *testing.T) {
+	var tNames = []struct {
+		name  string
+		parts []string // dot separated part is given with a . lead
+		more  bool
+	}{
+		{"simple", []string{"simple"}, false},
+		{"a key", []string{"a", "key"}, false},
+		{"^ Section", []string{"^", "Section"}, false},
+		{"dictname {", []string{"dictname", "{"}, false},
+		{"a.b.c d", []string{"a", ".b", ".c", "d"}, false},
+		{"a  b.c", []string{"a", "b", ".c"}, false},
+		{"a. b", []string{"a", ".b"}, false},
+		{"a..b", []string{"a", ".b"}, false},
+		{"a .b", []string{"a", ".b"}, false},
+		{"ab.", []string{"ab"}, false},
+		{"x.y.z.w.q", []string{"x", ".y", ".z", ".w.q"}, true},
+		{"abcdefghijklmnopqrstuvwxyz0123456789 x", []string{"abcdefghijklmnopqrstuvwxyz0123456789 x"}, true},
+		{"^^^SSSub", []string{"^^^SSSub"}, false},
+		{"' spkey", []string{" spkey"}, false},
+		{"'  a b", []string{"  a", "b"}, false},
+	}
+	var oc OcFlat
+	for _, tn := range tNames {
+		Reset(&oc, []byte(" "+tn.name+" : v\n"), false)
+		if ok := oc.Tokenize(); !ok || len(oc.Items) != 1 {
+			t.Errorf("Bad. »%s« should parse to a single item but it did not!", tn.name)
+			continue
+		}
+		parts, more := oc.NameParts(0)
+		if more != tn.more {
+			t.Errorf("Bad more flag for »%s«. Expected %v got %v", tn.name, tn.more, more)
+		}
+		if len(parts) != len(tn.parts) {
+			t.Errorf("Bad № of parts for »%s«. Expected %d got %d", tn.name, len(tn.parts), len(parts))
+			continue
+		}
+		for k, p := range parts {
+			s := string(oc.Inbuf[p.S:p.E])
+			if p.Dot {
+				s = "." + s
+			}
+			if s != tn.parts[k] {
+				t.Errorf("Bad part %d of »%s«. Expected »%s« got »%s«", k, tn.name, tn.parts[k], s)
+			}
+		}
+	}
+}
@@ -12,12 +12,16 @@ func Reset(oc *OcFlat, newbuf []byte, all bool) {
 	oc.LapsesFound = 0
 	oc.Items = nil  // release early as we may further
 	oc.Lapses = nil // pressure TokenizeLint with GBytes of input
+	oc.groups = nil
+	oc.Comments = nil
 	oc.BadLint = OcLint{}
 	if newbuf != nil {
 		oc.Inbuf = []byte(newbuf)
 	}
 	if all {
 		oc.linePragmas = lpDispatch{}
+		oc.Resolvers = nil
+		oc.KeepComments = false
 		oc.Pck = 0
 		oc.Sck = 0
 		oc.Mck = 0
@@ -439,6 +443,9 @@ func TokenizeLint(oc *OcFlat) (ok bool) {
 		oc.BadLint = OcLint{ln, LintBadEndLin}
 		return false
 	}
+	if oc.KeepComments {
+		oc.keepComments()
+	}
 	return true
 } // func TokenizeLint(oc *Parser) (ok bool)
 
@@ -0,0 +1,151 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Lookup returns the node at the canonical path, eg:
+//
+//	/Section/SubSect/SSSub.key
+//	/Section/SubSect[34]
+//	/OthSect/SubDict/listname/34/deepdict/deep/
+//
+// Path starts at the root with a slash and gives names of sections, keys
+// and brackets separated by slashes. An ORD member is addressed by its
+// index: either as a "[n]" suffix to the name of its container or as an
+// all digits segment. The last name may be joined to its container with
+// a dot, as in "SSSub.key"; a name that has dots of its own is tried
+// first as a whole. Names are compared in the canonical form, as Build
+// compares them, so "/a key" finds the "a  key" member. Trailing slash
+// is ignored. Groups are transparent: their members are looked up as
+// members of the node the group is in.
+// Value of a found leaf is given by the Oc.Logical(n.Item).
+//
+// If path can not be followed Lookup returns an *OcPathError pointing at
+// the failing segment. Build must be called before Lookup.
+func (t *OcTree) Lookup(path string) (n *OcNode, err error) {
+	if len(path) == 0 || path[0] != '/' {
+		return nil, &OcPathError{Path: path, Msg: "path must start with a /"}
+	}
+	n = t.Root
+	for p := 1; p < len(path); {
+		e := strings.IndexByte(path[p:], '/')
+		if e < 0 {
+			e = len(path)
+		} else {
+			e += p
+		}
+		if n, err = t.segment(n, path, p, e); err != nil {
+			return nil, err
+		}
+		p = e + 1
+	}
+	return
+}
+
+// func segment follows path[p:e] segment from the n node.
+func (t *OcTree) segment(n *OcNode, path string, p, e int) (*OcNode, error) {
+	seg := path[p:e]
+	bad := func(at int, msg string) error {
+		return &OcPathError{Path: path, Pos: at, Msg: msg}
+	}
+	ix := strings.IndexByte(seg, '[')
+	if ix < 0 {
+		ix = len(seg)
+	}
+	switch name := seg[:ix]; {
+	case seg == "":
+		return nil, bad(p, "empty segment")
+	case name == "":
+	case isDigits(name):
+		k, _ := strconv.Atoi(name)
+		if n = n.ord(k); n == nil {
+			return nil, bad(p, "no member at index "+name)
+		}
+	default:
+		if n = n.named(name); n == nil {
+			return nil, bad(p, "no "+strconv.Quote(name)+" found")
+		}
+	}
+	for q := p + ix; q < e; { // [n] suffixes
+		c := strings.IndexByte(path[q:e], ']')
+		if path[q] != '[' || c < 0 || !isDigits(path[q+1:q+c]) {
+			return nil, bad(q, "malformed [index]")
+		}
+		k, _ := strconv.Atoi(path[q+1 : q+c])
+		if n = n.ord(k); n == nil {
+			return nil, bad(q, "no member at index "+path[q:q+c+1])
+		}
+		q += c + 1
+	}
+	return n, nil
+}
+
+// func named returns the named member of n, or nil. Names are compared
+// in the canonical form. Name that is not found as a whole is split at
+// its dots into a member name and a name within that member.
+func (n *OcNode) named(name string) *OcNode {
+	return n.find(canonName(name), make(map[nameAt]*OcNode))
+}
+
+// nameAt is a canonical name looked up within a node.
+type nameAt struct {
+	n    *OcNode
+	name string
+}
+
+// func find does the named job, with results of lookups done so far kept
+// in the memo, so each dot split of the name is tried once per node.
+func (n *OcNode) find(name string, memo map[nameAt]*OcNode) *OcNode {
+	at := nameAt{n, name}
+	if r, ok := memo[at]; ok {
+		return r
+	}
+	memo[at] = nil
+	r := n.member(func(k *OcNode) bool { return k.Index < 0 && canonName(k.Name) == name })
+	for d := 0; r == nil && d < len(name); d++ {
+		if name[d] != '.' {
+			continue
+		}
+		if up := n.find(name[:d], memo); up != nil {
+			r = up.find(name[d+1:], memo)
+		}
+	}
+	memo[at] = r
+	return r
+}
+
+// func ord returns the ORD member of n at index k, or nil.
+func (n *OcNode) ord(k int) *OcNode {
+	return n.member(func(m *OcNode) bool { return m.Index == k })
+}
+
+// func member returns the first member of n that matches, looking
+// through groups.
+func (n *OcNode) member(match func(*OcNode) bool) *OcNode {
+	for _, k := range n.Kids {
+		if k.Kind == NodeGroup {
+			if r := k.member(match); r != nil {
+				return r
+			}
+		} else if match(k) {
+			return k
+		}
+	}
+	return nil
+}
+
+// func isDigits tells whether s is a non empty string of ascii digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
@@ -0,0 +1,108 @@
+package octok
+
+import (
+	"strings"
+	"testing"
+)
+
+const tLookup string = `  top : at root
+ ^ Section : ---
+  ^^ SubSect : ---
+            : member 0
+            : member 1
+   ^^^ SSSub : ---
+         key : deep key
+ ^ OthSect : ---
+       key : oth key
+     a.dot : dotted
+    a  key : spaced
+  ^^ SubDict : ---
+    listname [ :
+               : list member 0
+               < : anon set
+                 : set member 0
+        deepdict { :
+              deep : value here
+                 } :
+               > :
+               ( : ^.
+               : grouped 2
+               ) :
+             ] :
+`
+
+func TestLookup(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tLookup)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Lookup test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Lookup tree should build but it did not: %s", err)
+	}
+	for _, te := range []struct{ path, val string }{
+		{"/top", "at root"},
+		{"/Section/SubSect[1]", "member 1"},
+		{"/Section/SubSect/0", "member 0"},
+		{"/Section/SubSect/SSSub.key", "deep key"},
+		{"/Section/SubSect/SSSub/key", "deep key"},
+		{"/OthSect.key", "oth key"},
+		{"/OthSect/a.dot", "dotted"},
+		{"/OthSect/a key", "spaced"},
+		{"/OthSect.a  key", "spaced"},
+		{"/OthSect/SubDict/listname/1/deepdict/deep/", "value here"},
+		{"/OthSect/SubDict/listname[1][0]", "set member 0"},
+		{"/OthSect/SubDict.listname[2]", "grouped 2\n"},
+	} {
+		n, err := tr.Lookup(te.path)
+		if err != nil {
+			t.Errorf("Bad. %s should be found but it was not: %s", te.path, err)
+			continue
+		}
+		if lv, err := oc.Logical(n.Item); err != nil || string(lv.Val) != te.val {
+			t.Errorf("Bad. %s should give »%s« got »%s« (%v)", te.path, te.val, lv.Val, err)
+		}
+	}
+	if n, err := tr.Lookup("/"); err != nil || n != tr.Root {
+		t.Errorf("Bad. / should give the root node (%v)", err)
+	}
+	for _, te := range []struct {
+		path string
+		pos  int
+	}{
+		{"top", 0},
+		{"/Nope/key", 1},
+		{"/Section/SubSect/2", 17},
+		{"/Section/SubSect[2]", 16},
+		{"/Section/SubSect[x]", 16},
+		{"/Section//SubSect", 9},
+		{"/top/more", 5},
+		{"/OthSect.a", 1},
+	} {
+		if _, err := tr.Lookup(te.path); err == nil {
+			t.Errorf("Bad. %s should not be found but it was!", te.path)
+		} else if e := err.(*OcPathError); e.Pos != te.pos {
+			t.Errorf("Bad. %s error should point at %d, points at %d: %s", te.path, te.pos, e.Pos, e)
+		}
+	}
+}
+
+func TestLookupManyDots(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte("x { :\n x { :\n  x : v\n } :\n} :\n")
+	if !oc.Tokenize() {
+		t.Fatalf("Bad. Dotted config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Dotted tree should build but it did not: %s", err)
+	}
+	name := "/x" + strings.Repeat(".x", 44)
+	if _, err := tr.Lookup(name); err == nil {
+		t.Errorf("Bad. %s should not be found but it was!", name)
+	}
+	if n, err := tr.Lookup("/x.x.x"); err != nil || oc.RawValueStr(n.Item) != "v" {
+		t.Errorf("Bad. /x.x.x should be found (%v)", err)
+	}
+}
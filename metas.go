@@ -0,0 +1,72 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+// MetaList splits metas of the i-th Item into an ordered list of single
+// metas. Recognized are (with inner text shown as …):
+//
+//	@…;       annotation
+//	=…/ &…/   reference: to be copied and aliased, respectively
+//	(…) […] {…} <…>
+//
+// Metas can be chained, even of different kinds, eg. "@a;<b>=/x/". As
+// Tokenize finds metas going back from the pragma dot, so does MetaList:
+// inner text may contain any character but the opener of its own kind.
+// After ExpandGroups, metas given to enclosing group openers follow the
+// Item's own ones, from the innermost group out. Meta.Item tells where
+// it came from.
+func (oc *OcFlat) MetaList(i int) (ml []OcMeta, err error) {
+	for g := i; g >= 0; g = oc.GroupOf(g) {
+		if ml, err = oc.appendMetas(ml, g); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// func appendMetas parses metas span of the i-th Item.
+func (oc *OcFlat) appendMetas(ml []OcMeta, i int) ([]OcMeta, error) {
+	it := &oc.Items[i]
+	if it.Ms == it.Pe {
+		return ml, nil
+	}
+	b := oc.Inbuf
+	from := len(ml)
+	for p := int(it.Pe) - 2; p >= int(it.Ms); p-- { // Pe-1 is the dot
+		var d, e byte
+		switch o := b[p]; o {
+		case ';':
+			d = '@'
+		case ')':
+			d = '('
+		case '/':
+			d, e = '=', '&'
+		case '>', ']', '}':
+			d = o - 2
+		}
+		m := OcMeta{E: uint32(p), Item: i}
+		for p--; p >= int(it.Ms); p-- {
+			if c := b[p]; c == d || c == e {
+				m.Kind = c
+				break
+			}
+		}
+		if d == 0 || p < int(it.Ms) {
+			return ml[:from], &OcError{Pos: it.Ms, Line: oc.ItemLine(i),
+				Msg: "malformed metas " + oc.MetasStr(i)}
+		}
+		m.S = uint32(p + 1)
+		ml = append(ml, m)
+	}
+	for l, r := from, len(ml)-1; l < r; l, r = l+1, r-1 { // make in order
+		ml[l], ml[r] = ml[r], ml[l]
+	}
+	return ml, nil
+}
+
+// MetaText returns the inner text of the m meta.
+func (oc *OcFlat) MetaText(m OcMeta) []byte {
+	return oc.Inbuf[m.S:m.E:m.E]
+}
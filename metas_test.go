@@ -0,0 +1,47 @@
+package octok
+
+import "testing"
+
+const tMetas string = `     one : v @ann;.
+   chain : v +@ann;=/Sect.key/&/Other/(paren)[sq]{cur}<ang>.
+    semi : v @a;b;.
+    none : v +.
+         : w
+       ( : grp @grp;.
+   inner : v <own>.
+       ) :
+`
+
+func TestMetaList(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tMetas)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Metas test config should parse but it did not!")
+	}
+	if err := oc.ExpandGroups(); err != nil {
+		t.Fatalf("Bad. Metas test groups should expand: %s", err)
+	}
+	for i, exp := range [][]string{
+		{"@ann"},
+		{"@ann", "=/Sect.key", "&/Other", "(paren", "[sq", "{cur", "<ang"},
+		{"@a;b"},
+		nil,
+		nil,
+		{"@grp"},
+		{"<own", "@grp"},
+	} {
+		ml, err := oc.MetaList(i)
+		if err != nil || len(ml) != len(exp) {
+			t.Errorf("Bad. Item %d should have %d metas, got %d (%v)", i, len(exp), len(ml), err)
+			continue
+		}
+		for k, m := range ml {
+			if s := string(m.Kind) + string(oc.MetaText(m)); s != exp[k] {
+				t.Errorf("Bad meta %d of item %d. Expected »%s« got »%s«", k, i, exp[k], s)
+			}
+		}
+	}
+	if ml, _ := oc.MetaList(6); len(ml) == 2 && (ml[0].Item != 6 || ml[1].Item != 5) {
+		t.Errorf("Bad. Metas should tell where they came from, got %d and %d", ml[0].Item, ml[1].Item)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import "strings"
+
+// Origin returns the node n was inherited from, following all levels of
+// model derivation, or n itself if it was not inherited. Origin's Item
+// tells where the effective value of n was given.
+func (n *OcNode) Origin() *OcNode {
+	for n.From != nil {
+		n = n.From
+	}
+	return n
+}
+
+// func derive merges members of models into sections that derive from
+// them. Called by Build.
+func (t *OcTree) derive() error {
+	d := deriver{t: t, busy: make(map[*OcNode]bool), done: make(map[*OcNode]bool)}
+	return d.all(t.Root)
+}
+
+// deriver keeps state of a derive run.
+type deriver struct {
+	t    *OcTree
+	busy map[*OcNode]bool // sections being derived, to spot cycles
+	done map[*OcNode]bool // sections derived already
+}
+
+// func all derives every section below n.
+func (d *deriver) all(n *OcNode) error {
+	for _, k := range n.Kids {
+		if k.Kind != NodeSection && k.Kind != NodeModel {
+			continue
+		}
+		if err := d.one(k); err != nil {
+			return err
+		}
+		if err := d.all(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func one derives n section from the model its value names, if any.
+// Only a value of a single "@Name" word names a model, any other one is
+// a decoration.
+func (d *deriver) one(n *OcNode) error {
+	if d.done[n] {
+		return nil
+	}
+	oc := d.t.Oc
+	ref := strings.Fields(oc.RawValueStr(n.Item))
+	if len(ref) != 1 || len(ref[0]) < 2 || ref[0][0] != SectLeadEx {
+		d.done[n] = true
+		return nil
+	}
+	if d.busy[n] {
+		return d.t.errAt(n.Item, "model derivation cycle through "+ref[0])
+	}
+	b := d.t.model(n, ref[0][1:])
+	switch {
+	case b == nil:
+		return d.t.errAt(n.Item, "model "+ref[0][1:]+" not found")
+	case b.Kind != NodeModel:
+		return d.t.errAt(n.Item, ref[0][1:]+" given at line "+
+			lineStr(oc.ItemLine(b.Item))+" is not a model section")
+	}
+	d.busy[n] = true
+	err := d.one(b)
+	if err == nil {
+		err = d.all(b)
+	}
+	delete(d.busy, n)
+	if err != nil {
+		return err
+	}
+	var kids []*OcNode
+	for _, k := range b.Kids {
+		if c := n.inherit(k, n, n.Depth-b.Depth); c != nil {
+			kids = append(kids, c)
+		}
+	}
+	n.Kids = append(kids, n.Kids...)
+	if b.top > n.top {
+		n.top = b.top
+	}
+	n.Base = b
+	d.done[n] = true
+	return nil
+}
+
+// func model finds the model named by ref for the n section: a path, or
+// a name of a sibling section, or of a sibling of a section up.
+func (t *OcTree) model(n *OcNode, ref string) *OcNode {
+	if ref[0] == '/' {
+		m, _ := t.Lookup(ref)
+		return m
+	}
+	for up := n.Up; up != nil; up = up.Up {
+		for _, k := range up.Kids {
+			if k != n && k.isSection() && k.Name == ref {
+				return k
+			}
+		}
+	}
+	return nil
+}
+
+// func inherit returns a copy of the k model member to be put under the
+// up node, or nil if own member of the n section overrides k. Members
+// of groups are checked one by one. Section depths are moved by dd.
+func (n *OcNode) inherit(k, up *OcNode, dd int) *OcNode {
+	switch {
+	case k.Kind != NodeGroup && k.Index >= 0:
+		if n.ord(k.Index) != nil {
+			return nil
+		}
+	case k.Kind != NodeGroup:
+		if n.member(func(o *OcNode) bool { return o.Index < 0 && o.Name == k.Name }) != nil {
+			return nil
+		}
+	default:
+		c := *k
+		c.Up, c.From, c.Kids = up, k, nil
+		for _, kk := range k.Kids {
+			if g := n.inherit(kk, &c, dd); g != nil {
+				c.Kids = append(c.Kids, g)
+			}
+		}
+		return &c
+	}
+	return k.copyTo(up, dd)
+}
+
+// func copyTo returns a deep copy of k to be put under the up node.
+// Model subsections copied into a section that is not a model become
+// sections.
+func (k *OcNode) copyTo(up *OcNode, dd int) *OcNode {
+	c := *k
+	c.Up, c.From, c.Kids = up, k, nil
+	if c.isSection() {
+		c.Depth += dd
+	}
+	if c.Kind == NodeModel && up.scope().Kind != NodeModel {
+		c.Kind = NodeSection
+	}
+	for _, kk := range k.Kids {
+		c.Kids = append(c.Kids, kk.copyTo(&c, dd))
+	}
+	return &c
+}
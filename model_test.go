@@ -0,0 +1,115 @@
+package octok
+
+import (
+	"strings"
+	"testing"
+)
+
+const tModels string = ` @ Base : --- model ---
+     host : localhost
+     port : 8080
+          : ord zero
+          : ord one
+   ^^ Log : ---
+    level : info
+ @ Secure : @Base
+     port : 8443
+      tls : on
+ ^ Server : @Secure
+     host : example.com
+        1 : own one
+ ^ Plain : --- no model ---
+     host : plain
+`
+
+func TestModels(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tModels)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Models test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Models tree should build but it did not: %s", err)
+	}
+	var out []string
+	treeDump(tr.Root.Kids[2], "", &out)
+	exp := []string{"Server", " ", " Log", "  level", " port", " tls", " host", " "}
+	if strings.Join(out, "|") != strings.Join(exp, "|") {
+		t.Errorf("Bad derived tree.\nExpected: %q\n     Got: %q", exp, out)
+	}
+	for _, te := range []struct {
+		path, val string
+		line      uint32
+	}{
+		{"/Server/host", "example.com", 12},
+		{"/Server/port", "8443", 9},
+		{"/Server/tls", "on", 10},
+		{"/Server/Log/level", "info", 7},
+		{"/Server/0", "ord zero", 4},
+		{"/Server/1", "own one", 13},
+		{"/Secure/host", "localhost", 2},
+	} {
+		n, err := tr.Lookup(te.path)
+		if err != nil {
+			t.Errorf("Bad. %s should be found but it was not: %s", te.path, err)
+			continue
+		}
+		o := n.Origin()
+		if v := oc.RawValueStr(n.Item); v != te.val || oc.ItemLine(o.Item) != te.line {
+			t.Errorf("Bad. %s should be »%s« from line %d, is »%s« from %d",
+				te.path, te.val, te.line, v, oc.ItemLine(o.Item))
+		}
+	}
+	srv := tr.Root.Kids[2]
+	if srv.Base != tr.Root.Kids[1] || srv.Kids[1].Depth != 2 || srv.Kids[1].Up != srv {
+		t.Errorf("Bad. Server should derive from Secure and adopt its Log subsection")
+	}
+	if n, _ := tr.Lookup("/Server/Log/level"); n.From == nil || n.From.From == nil || n.From.From.From != nil {
+		t.Errorf("Bad. Log/level should be inherited through two levels")
+	}
+	Reset(&oc, []byte("^ Sect : @admin only\n"), false)
+	if !oc.Tokenize() || tr.Build() != nil || tr.Root.Kids[0].Base != nil {
+		t.Errorf("Bad. Value of more than a @Name word should be a decoration")
+	}
+	for _, bad := range []struct {
+		conf string
+		line uint32
+	}{
+		{"@ A : @B\n@ B : @A\n", 1},
+		{"^ S : @Nope\n", 1},
+		{"^ T : ---\n^ S : @T\n", 2},
+		{"@ A : ---\n@@ B : @A\n", 2},
+	} {
+		Reset(&oc, []byte(bad.conf), false)
+		if ok := oc.Tokenize(); !ok {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad.conf)
+			continue
+		}
+		if err := tr.Build(); err == nil {
+			t.Errorf("Bad. »%s« should not build but it did!", bad.conf)
+		} else if err.(*OcError).Line != bad.line {
+			t.Errorf("Bad. »%s« should err at line %d, got: %s", bad.conf, bad.line, err)
+		}
+	}
+}
+
+func TestModelsDecode(t *testing.T) {
+	conf := "@ M : ---\n  x : 1\n@@ Sub : ---\n  z : deep\n^ S : @M\n  x : 2\n"
+	var c struct {
+		S struct {
+			X   int
+			Sub struct{ Z string }
+		}
+	}
+	if err := Unmarshal([]byte(conf), &c); err != nil || c.S.X != 2 || c.S.Sub.Z != "deep" {
+		t.Errorf("Bad. Inherited model subsection should decode: %+v (%v)", c, err)
+	}
+	var m map[string]interface{}
+	if err := Unmarshal([]byte(conf), &m); err != nil {
+		t.Fatalf("Bad. Models config should decode into a map: %s", err)
+	}
+	if s, _ := m["S"].(map[string]interface{}); s == nil || s["Sub"] == nil || m["M"] != nil {
+		t.Errorf("Bad. Map should hold S with Sub and no M model: %v", m)
+	}
+}
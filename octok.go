@@ -17,7 +17,8 @@ package octok
 // line lacked an ending newline, or registered line pragma failed. Parsed
 // Oconf ITEMs are filled into OcFlat.Items field. If (OcFlat.LintFull ==
 // true), the OcFlat.Lapses table is filled. Otherwise, spotted lapses are
-// simply counted in the OcFlat.LapsesFound counter.
+// simply counted in the OcFlat.LapsesFound counter. With OcFlat.KeepComments
+// set, comment lines and remarks are filled into OcFlat.Comments.
 func (oc *OcFlat) Tokenize() (ok bool) {
 	var nowStage, fromStage pStage // parse stages
 	var afterS, lastP int          // position markers
@@ -433,6 +434,9 @@ func (oc *OcFlat) Tokenize() (ok bool) {
 		oc.BadLint = OcLint{ln, LintBadEndLin}
 		return false
 	}
+	if oc.KeepComments {
+		oc.keepComments()
+	}
 	return true
 } // func (oc *Flat) Tokenize (ok bool)
 
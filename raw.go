@@ -0,0 +1,70 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import "bytes"
+
+// Raw returns the :== raw value of the i-th Item with CRLF line endings
+// normalized to LF. With dedent set, indentation common to all not blank
+// lines is removed and blank lines (including the part of the boundary
+// line before the boundary) are emptied - so a block indented below its
+// header comes out as it would be written from the first column.
+// OcRaw tells also the boundary that ended the block (either custom one
+// or the ==RawEnd default) and the Inbuf lines the block spans.
+func (oc *OcFlat) Raw(i int, dedent bool) (r OcRaw, err error) {
+	if !oc.IsRaw(i) {
+		return r, &OcError{Pos: oc.Items[i].Vs, Line: oc.ItemLine(i),
+			Msg: "not a :== raw value"}
+	}
+	it := &oc.Items[i]
+	r.Boundary = string(oc.Inbuf[it.Ve : it.Ve+8]) // Tokenize made sure
+	r.First = oc.LineOf(it.Vs)
+	r.Last = oc.LineOf(it.Ve)
+	v := bytes.Replace(oc.RawValue(i), []byte("\r\n"), []byte("\n"), -1)
+	if !dedent {
+		r.Val = v
+		return
+	}
+	lines := bytes.SplitAfter(v, []byte("\n"))
+	var ind []byte // common indentation
+	var got bool
+	for _, l := range lines {
+		n := blankLead(l)
+		if n == len(bytes.TrimRight(l, "\n")) { // blank line
+			continue
+		}
+		switch {
+		case !got:
+			ind = l[:n]
+			got = true
+		case !bytes.HasPrefix(l, ind):
+			k := 0
+			for k < len(ind) && k < n && l[k] == ind[k] {
+				k++
+			}
+			ind = ind[:k]
+		}
+	}
+	r.Val = make([]byte, 0, len(v))
+	for _, l := range lines {
+		n := blankLead(l)
+		switch {
+		case n == len(bytes.TrimRight(l, "\n")): // blank line
+			l = l[n:]
+		default:
+			l = l[len(ind):]
+		}
+		r.Val = append(r.Val, l...)
+	}
+	return
+}
+
+// func blankLead returns the number of leading spaces and tabs in l.
+func blankLead(l []byte) (n int) {
+	for n < len(l) && (l[n] == ' ' || l[n] == '\t') {
+		n++
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package octok
+
+import "testing"
+
+const tRaw string = "  cert :== xHereRaw\r\n" +
+	"     -----BEGIN-----\r\n" +
+	"       indented more\r\n" +
+	"\r\n" +
+	"     -----END-----\r\n" +
+	"  xHereRaw\r\n" +
+	"  dflt :==\n" +
+	"\tscript  ==RawEnd\n" +
+	"  name : value\n"
+
+func TestRaw(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tRaw)
+	if ok := oc.Tokenize(); !ok || len(oc.Items) != 3 {
+		t.Fatalf("Bad. Raw test config should parse to 3 items but it did not!")
+	}
+	r, err := oc.Raw(0, false)
+	if exp := "     -----BEGIN-----\n       indented more\n\n     -----END-----\n  "; err != nil || string(r.Val) != exp {
+		t.Errorf("Bad raw value. Expected »%q« got »%q« (%v)", exp, r.Val, err)
+	}
+	if r.Boundary != "xHereRaw" || r.First != 2 || r.Last != 6 {
+		t.Errorf("Bad raw boundary or lines: »%s« %d..%d", r.Boundary, r.First, r.Last)
+	}
+	r, err = oc.Raw(0, true)
+	if exp := "-----BEGIN-----\n  indented more\n\n-----END-----\n"; err != nil || string(r.Val) != exp {
+		t.Errorf("Bad dedented raw value. Expected »%q« got »%q« (%v)", exp, r.Val, err)
+	}
+	r, err = oc.Raw(1, true)
+	if err != nil || string(r.Val) != "script  " || r.Boundary != "==RawEnd" || r.First != 8 || r.Last != 8 {
+		t.Errorf("Bad default boundary raw value. Got »%q« »%s« %d..%d (%v)", r.Val, r.Boundary, r.First, r.Last, err)
+	}
+	if _, err = oc.Raw(2, true); err == nil {
+		t.Errorf("Bad. Not raw value should err but it did not!")
+	}
+	if string(oc.Inbuf) != tRaw {
+		t.Errorf("Bad. Raw changed the Inbuf!")
+	}
+}
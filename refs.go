@@ -0,0 +1,135 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+// Target returns the node that holds the value of n: the end of the
+// chain of =…/ and &…/ references given to leaves, or n itself if it
+// refers to nothing. Value of a leaf n is Oc.Logical(n.Target().Item).
+//
+// A leaf with a reference meta, eg. "name : =/Section/key/." or
+// "name : &/Section/sub/.", gets the Ref set to the node at the path (as
+// Lookup takes it). If the referred node is a section or a bracket, the
+// leaf takes its Kind and members: a =…/ copy gets copies of them (with
+// From set to the member copied), an &…/ alias shares the Kids with the
+// referred node. Either way a leaf with a reference must not have its own
+// value.
+func (n *OcNode) Target() *OcNode {
+	for n.Ref != nil {
+		n = n.Ref
+	}
+	return n
+}
+
+// func refer resolves references of all leaves. Called by Build.
+func (t *OcTree) refer() error {
+	r := referrer{t: t, busy: make(map[*OcNode]bool), done: make(map[*OcNode]bool)}
+	return r.all(t.Root)
+}
+
+// referrer keeps state of a refer run.
+type referrer struct {
+	t    *OcTree
+	busy map[*OcNode]bool // nodes being resolved, to spot cycles
+	done map[*OcNode]bool // nodes resolved already
+}
+
+// func all resolves references of n and of all nodes below.
+func (r *referrer) all(n *OcNode) error {
+	if err := r.one(n); err != nil {
+		return err
+	}
+	if n.Alias {
+		return nil // shared Kids are resolved where they belong
+	}
+	for _, k := range n.Kids {
+		if err := r.all(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func one resolves the reference the n leaf has, if any.
+func (r *referrer) one(n *OcNode) error {
+	if r.done[n] || n.Kind != NodeLeaf {
+		return nil
+	}
+	oc := r.t.Oc
+	m, err := r.t.refMeta(n)
+	if err != nil || m.Kind == 0 {
+		r.done[n] = true
+		return err
+	}
+	ref := string(oc.MetaText(m))
+	at := func(msg string) error {
+		return &OcError{Pos: m.S, Line: oc.ItemLine(n.Item), Msg: msg}
+	}
+	for k := n.Item; k <= n.Last; k++ {
+		if len(oc.RawValue(k)) > 0 {
+			return at("value given along with the " + string(m.Kind) + ref + "/ reference")
+		}
+	}
+	d, err := r.t.Lookup(ref)
+	if err != nil {
+		return at("dangling reference: " + err.Error())
+	}
+	if r.busy[n] {
+		return at("reference cycle through " + ref + " given at line " +
+			lineStr(oc.ItemLine(d.Item)))
+	}
+	r.busy[n] = true
+	err = r.all(d) // resolve what is to be copied or shared first
+	delete(r.busy, n)
+	if err != nil {
+		if e, ok := err.(*OcError); ok && e.Line != oc.ItemLine(n.Item) {
+			e.Msg += ", referred from line " + lineStr(oc.ItemLine(n.Item))
+		}
+		return err
+	}
+	n.Ref, n.Alias = d, m.Kind == '&'
+	if d.Kind != NodeLeaf {
+		n.Kind, n.top = d.Kind, d.top
+		if d.isSection() {
+			up := n.Up
+			for !up.isSection() {
+				up = up.Up
+			}
+			n.Depth = up.Depth + 1
+		}
+		if n.Alias {
+			n.Kids = d.Kids
+		} else {
+			for _, k := range d.Kids {
+				n.Kids = append(n.Kids, k.copyTo(n, n.Depth-d.Depth))
+			}
+		}
+	}
+	r.done[n] = true
+	return nil
+}
+
+// func refMeta returns the =…/ or &…/ meta given to the n leaf chain
+// Items or their groups. Returned Kind is 0 if there is none.
+func (t *OcTree) refMeta(n *OcNode) (m OcMeta, err error) {
+	oc := t.Oc
+	ml, err := oc.MetaList(n.Item)
+	for k := n.Item + 1; k <= n.Last && err == nil; k++ {
+		ml, err = oc.appendMetas(ml, k)
+	}
+	if err != nil {
+		return
+	}
+	for _, mm := range ml {
+		if mm.Kind != '=' && mm.Kind != '&' {
+			continue
+		}
+		if m.Kind != 0 {
+			return m, &OcError{Pos: mm.S, Line: oc.ItemLine(mm.Item),
+				Msg: "more than one reference given"}
+		}
+		m = mm
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package octok
+
+import (
+	"strings"
+	"testing"
+)
+
+const tRefs string = `  top : =/Section/key/.
+ ^ Section : ---
+       key : value
+      list [ :
+           : zero
+           : one
+           ] :
+  ^^ Sub : ---
+     deep : down
+ ^ Other : ---
+     copy : =/Section/Sub/.
+    alias : &/Section/list/.
+    chain : &/top/.
+     grp ( : =/Section/list[1]/.
+         :
+         ) :
+`
+
+func TestRefs(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tRefs)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Refs test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Refs tree should build but it did not: %s", err)
+	}
+	for _, te := range []struct{ path, val string }{
+		{"/top", "value"},
+		{"/Other/chain", "value"},
+		{"/Other/copy/deep", "down"},
+		{"/Other/alias[1]", "one"},
+		{"/Other/0", "one"},
+	} {
+		n, err := tr.Lookup(te.path)
+		if err != nil {
+			t.Errorf("Bad. %s should be found but it was not: %s", te.path, err)
+			continue
+		}
+		if v := oc.RawValueStr(n.Target().Item); v != te.val {
+			t.Errorf("Bad. %s should give »%s« got »%s«", te.path, te.val, v)
+		}
+	}
+	cp, _ := tr.Lookup("/Other/copy")
+	al, _ := tr.Lookup("/Other/alias")
+	sub, _ := tr.Lookup("/Section/Sub")
+	list, _ := tr.Lookup("/Section/list")
+	if cp.Kind != NodeSection || cp.Alias || cp.Depth != 2 || cp.Kids[0] == sub.Kids[0] ||
+		cp.Kids[0].From != sub.Kids[0] || cp.Kids[0].Up != cp {
+		t.Errorf("Bad. Copy should get copies of the section members")
+	}
+	if al.Kind != NodeList || !al.Alias || &al.Kids[0] != &list.Kids[0] || len(al.Dense()) != 2 {
+		t.Errorf("Bad. Alias should share members of the list")
+	}
+	for _, bad := range []struct {
+		conf string
+		line uint32
+		msg  string
+	}{
+		{"a : =/b/.\nb : =/a/.\n", 1, "cycle through /b given at line 2"},
+		{"a : =/a/.\n", 1, "cycle"},
+		{"^ S : ---\nx : &/S/.\n", 2, "cycle"},
+		{"a : =/nope/.\n", 1, "dangling"},
+		{"a : x =/b/.\nb : v\n", 1, "value given"},
+		{"a : =/b/&/b/.\nb : v\n", 1, "more than one"},
+		{"a : =/b/.\nb : =/c/.\nc : =/b/.\n", 2, "referred from line 1"},
+	} {
+		Reset(&oc, []byte(bad.conf), false)
+		if ok := oc.Tokenize(); !ok {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad.conf)
+			continue
+		}
+		if err := tr.Build(); err == nil {
+			t.Errorf("Bad. »%s« should not build but it did!", bad.conf)
+		} else if e := err.(*OcError); e.Line != bad.line || !strings.Contains(e.Msg, bad.msg) {
+			t.Errorf("Bad. »%s« should err at line %d with %q, got: %s", bad.conf, bad.line, bad.msg, err)
+		}
+	}
+}
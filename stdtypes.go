@@ -0,0 +1,177 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a size in bytes, given in the config in a human form: as
+// a number with an optional unit, eg. "512", "64KiB", "1.5 GB" or "10k".
+// Units are B, K (or KB), M, G, T, P and E of the powers of 1000, and
+// Ki (or KiB), Mi, Gi, Ti, Pi and Ei of the powers of 1024. Unit letters
+// case does not matter.
+type ByteSize uint64
+
+// String returns the size with the largest binary unit it is a multiple
+// of, eg. "64KiB", or in bytes, eg. "1000".
+func (b ByteSize) String() string {
+	u := 0
+	for u < len(sizeUnits) && b != 0 && b%1024 == 0 {
+		b /= 1024
+		u++
+	}
+	s := strconv.FormatUint(uint64(b), 10)
+	if u > 0 {
+		s += string(sizeUnits[u-1]) + "iB"
+	}
+	return s
+}
+
+// sizeUnits are ByteSize unit letters, by power.
+const sizeUnits = "KMGTPE"
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	d := 0
+	for d < len(s) && (s[d] >= '0' && s[d] <= '9' || s[d] == '.') {
+		d++
+	}
+	bad := errors.New("value »" + s + "« is not a byte size")
+	mul, unit := uint64(1), strings.ToUpper(strings.TrimSpace(s[d:]))
+	switch {
+	case unit == "" || unit == "B":
+	case len(unit) > 3:
+		return bad
+	default:
+		p := strings.IndexByte(sizeUnits, unit[0])
+		k := uint64(1000)
+		switch unit[1:] {
+		case "", "B":
+		case "I", "IB":
+			k = 1024
+		default:
+			return bad
+		}
+		if p < 0 {
+			return bad
+		}
+		for ; p >= 0; p-- {
+			mul *= k
+		}
+	}
+	if strings.IndexByte(s[:d], '.') >= 0 {
+		f, err := strconv.ParseFloat(s[:d], 64)
+		if err != nil || f*float64(mul) >= 1<<64 {
+			return bad
+		}
+		*b = ByteSize(f * float64(mul))
+		return nil
+	}
+	n, err := strconv.ParseUint(s[:d], 10, 64)
+	if err != nil || n > (1<<64-1)/mul {
+		return bad
+	}
+	*b = ByteSize(n * mul)
+	return nil
+}
+
+// stdTypes are types of the Go library the decoder and Marshal know.
+var stdTypes = map[reflect.Type]bool{
+	reflect.TypeOf(time.Duration(0)): true,
+	reflect.TypeOf(time.Time{}):      true,
+	reflect.TypeOf(net.IP{}):         true,
+	reflect.TypeOf(net.IPNet{}):      true,
+	reflect.TypeOf(url.URL{}):        true,
+}
+
+// func stdLeaf stores the n leaf in v of one of the stdTypes.
+func (d *OcDecoder) stdLeaf(n *OcNode, v reflect.Value) error {
+	if n.Kind != NodeLeaf {
+		return d.errAt(n, "can not put a "+kindName[n.Kind]+" into "+v.Type().String())
+	}
+	_, lv, err := d.value(n)
+	if err != nil {
+		return err
+	}
+	if lv.Tc == TcNull {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	return d.std(n, v, strings.TrimSpace(string(lv.Val)))
+}
+
+// func std parses s, given as the n value, into v of one of the stdTypes.
+func (d *OcDecoder) std(n *OcNode, v reflect.Value, s string) error {
+	var x interface{}
+	var what string
+	switch v.Interface().(type) {
+	case time.Duration:
+		what = "a duration"
+		if dv, err := time.ParseDuration(s); err == nil {
+			x = dv
+		}
+	case time.Time:
+		what = "a time"
+		for _, l := range append([]string{time.RFC3339}, d.TimeLayouts...) {
+			if tv, err := time.Parse(l, s); err == nil {
+				x = tv
+				break
+			}
+		}
+	case net.IP:
+		what = "an IP address"
+		if ip := net.ParseIP(s); ip != nil {
+			x = ip
+		}
+	case net.IPNet:
+		what = "a CIDR network"
+		if _, nv, err := net.ParseCIDR(s); err == nil {
+			x = *nv
+		}
+	case url.URL:
+		what = "a URL"
+		if u, err := url.Parse(s); err == nil && s != "" {
+			x = *u
+		}
+	}
+	if x == nil {
+		return d.errAt(n, "value »"+s+"« is not "+what)
+	}
+	v.Set(reflect.ValueOf(x))
+	return nil
+}
+
+// func stdText returns the text of v of one of the stdTypes.
+func stdText(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		return x.String()
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case net.IP:
+		if len(x) == 0 {
+			return ""
+		}
+		return x.String()
+	case net.IPNet:
+		return x.String()
+	case url.URL:
+		return x.String()
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package octok
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tStd string = ` timeout : 30s
+   start : 2019-07-11T10:00:00Z
+     day : 2019-07-12
+  listen : 10.0.0.1
+     net : 10.0.0.0/8
+     api : https://example.com/v1?k=v
+   cache : 64KiB
+   burst : 1.5 kB
+  ^ Retry : ---
+    waits : 1s 5s 1m  *.
+    sizes : 1k 2Mi  *.
+     none : -.
+`
+
+type tStdRetry struct {
+	Waits []time.Duration
+	Sizes []ByteSize
+	None  *time.Duration
+}
+
+type tStdConf struct {
+	Timeout time.Duration
+	Start   time.Time
+	Day     time.Time
+	Listen  net.IP
+	Net     net.IPNet
+	API     *url.URL
+	Cache   ByteSize
+	Burst   ByteSize
+	Retry   tStdRetry
+}
+
+func TestStdTypes(t *testing.T) {
+	d := OcDecoder{TimeLayouts: []string{"2006-01-02"}}
+	var c tStdConf
+	if err := d.Decode([]byte(tStd), &c); err != nil {
+		t.Fatalf("Bad. Std types config should decode but it did not: %s", err)
+	}
+	u, _ := url.Parse("https://example.com/v1?k=v")
+	exp := tStdConf{
+		Timeout: 30 * time.Second,
+		Start:   time.Date(2019, 7, 11, 10, 0, 0, 0, time.UTC),
+		Day:     time.Date(2019, 7, 12, 0, 0, 0, 0, time.UTC),
+		Listen:  net.ParseIP("10.0.0.1"),
+		Net:     net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+		API:     u,
+		Cache:   64 << 10,
+		Burst:   1500,
+		Retry: tStdRetry{
+			Waits: []time.Duration{time.Second, 5 * time.Second, time.Minute},
+			Sizes: []ByteSize{1000, 2 << 20},
+		},
+	}
+	if !reflect.DeepEqual(c, exp) {
+		t.Errorf("Bad std types decode.\nExpected: %+v\n     Got: %+v", exp, c)
+	}
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatalf("Bad. Std types should marshal but they did not: %s", err)
+	}
+	for _, l := range []string{"Timeout : 30s\n", "Day : 2019-07-12T00:00:00Z\n",
+		"Net : 10.0.0.0/8\n", "Cache : 64KiB\n", "  : 2MiB\n"} {
+		if !strings.Contains(string(out), l) {
+			t.Errorf("Bad. Marshal output should have »%s«:\n%s", l, out)
+		}
+	}
+	var back tStdConf
+	if err := Unmarshal(out, &back); err != nil || !reflect.DeepEqual(back, exp) {
+		t.Errorf("Bad. Std types should read back (%v):\n%s", err, out)
+	}
+}
+
+func TestStdTypesErrors(t *testing.T) {
+	for _, bad := range []struct {
+		conf string
+		line uint32
+		msg  string
+	}{
+		{"timeout : 30\n", 1, "not a duration"},
+		{"\nstart : 2019-07-12\n", 2, "not a time"},
+		{"listen : 10.0.0.256\n", 1, "not an IP address"},
+		{"net : 10.0.0.1\n", 1, "not a CIDR network"},
+		{"api : ::\n", 1, "not a URL"},
+		{"cache : 64XB\n", 1, "not a byte size"},
+		{"cache : 99999999999 EiB\n", 1, "not a byte size"},
+		{"\n\n^ Retry : ---\n waits : 1s x  *.\n", 4, "»x« is not a duration"},
+		{"^ Timeout : ---\n", 1, "can not put a section"},
+	} {
+		var c tStdConf
+		err := Unmarshal([]byte(bad.conf), &c)
+		if e, ok := err.(*OcError); !ok || e.Line != bad.line || !strings.Contains(e.Msg, bad.msg) {
+			t.Errorf("Bad. »%s« should err at line %d with »%s«, got: %v",
+				bad.conf, bad.line, bad.msg, err)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out ByteSize
+		str string
+	}{
+		{"0", 0, "0"},
+		{"512", 512, "512"},
+		{"512 B", 512, "512"},
+		{"1000", 1000, "1000"},
+		{"1024", 1024, "1KiB"},
+		{"10k", 10000, "10000"},
+		{"1.5KiB", 1536, "1536"},
+		{"3 gib", 3 << 30, "3GiB"},
+		{"16EiB", 0, ""},
+		{"15EiB", 15 << 60, "15EiB"},
+		{"KiB", 0, ""},
+		{"1.2.3", 0, ""},
+	} {
+		var b ByteSize
+		err := b.UnmarshalText([]byte(tc.in))
+		switch {
+		case tc.str == "" && err == nil:
+			t.Errorf("Bad. »%s« should not parse as a ByteSize, got %d", tc.in, b)
+		case tc.str != "" && (err != nil || b != tc.out || b.String() != tc.str):
+			t.Errorf("Bad. »%s« should be %d (%s), got %d (%s) %v", tc.in, tc.out, tc.str, b, b, err)
+		}
+	}
+}
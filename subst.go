@@ -0,0 +1,141 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+// func subst materializes src value of the i-th Item with the `. and \.
+// pragmas applied. Within a `. value every `ref` span is replaced by what
+// the OcFlat.Resolvers give for the ref, and a doubled backtick stands
+// for a single one. With \. the rest (but not the substitutions) is
+// unescaped.
+func (oc *OcFlat) subst(i int, src []byte) (dst []byte, err error) {
+	it := &oc.Items[i]
+	dst = make([]byte, 0, len(src)+len(src)/2)
+	bt := it.Fl&Backtick != 0
+	var s, p int // literal start, position
+	for ; p <= len(src); p++ {
+		if p < len(src) && (!bt || src[p] != '`') {
+			continue
+		}
+		if it.Fl&Unescape != 0 { // flush literal
+			if dst, err = AppendUnesc(dst, src[s:p]); err != nil {
+				e := err.(*OcError)
+				e.Pos += it.Vs + uint32(s)
+				e.Line = oc.LineOf(e.Pos)
+				return
+			}
+		} else {
+			dst = append(dst, src[s:p]...)
+		}
+		if p == len(src) {
+			break
+		}
+		switch q := bytes.IndexByte(src[p+1:], '`'); {
+		case q == 0: // `` stands for a backtick
+			dst = append(dst, '`')
+			p++
+		case q < 0:
+			return dst, &OcError{Pos: it.Vs + uint32(p),
+				Line: oc.ItemLine(i), Msg: "unterminated `reference"}
+		default:
+			ref := string(src[p+1 : p+1+q])
+			if dst, err = oc.resolve(dst, ref); err != nil {
+				return dst, &OcError{Pos: it.Vs + uint32(p),
+					Line: oc.ItemLine(i), Msg: "`" + ref + "`: " + err.Error()}
+			}
+			p += q + 1
+		}
+		s = p + 1
+	}
+	return
+}
+
+// func resolve asks registered Resolvers for ref and appends the
+// substitution to dst.
+func (oc *OcFlat) resolve(dst []byte, ref string) ([]byte, error) {
+	for _, r := range oc.Resolvers {
+		val, ok, err := r.Resolve(oc, ref)
+		if err != nil {
+			return dst, err
+		}
+		if ok {
+			return append(dst, val...), nil
+		}
+	}
+	return dst, errors.New("no resolver knows this reference")
+}
+
+// EnvResolver resolves `$NAME` references to the value of the NAME
+// environment variable. Not set variable is an error.
+type EnvResolver struct{}
+
+// Resolve implements Resolver interface.
+func (EnvResolver) Resolve(oc *OcFlat, ref string) (val []byte, ok bool, err error) {
+	if len(ref) < 2 || ref[0] != '$' {
+		return
+	}
+	s, ok := os.LookupEnv(ref[1:])
+	if !ok {
+		return nil, true, errors.New("environment variable " + ref[1:] + " is not set")
+	}
+	return []byte(s), true, nil
+}
+
+// KeyResolver resolves `/path` references to the logical value of other
+// key within the same config, with its own pragmas applied; a key that
+// refers to another one with a meta gives the target's value. Path is
+// given as to the OcTree Lookup, eg. `/Section/SubSect/key`,
+// `/Section.key` or `/Section/list[3]`. Key given before any section is
+// addressed as `/key`. Reference cycles and missing keys are reported as
+// errors.
+//
+// Tree given is used as long as it was built over the OcFlat being
+// resolved, otherwise it is built anew, with the Dups policy kept. The
+// OcDecoder Decode gives its own Tree to KeyResolvers of its Oc.
+type KeyResolver struct {
+	Tree *OcTree      // config structure; built on first use if nil
+	busy map[int]bool // items under resolution
+}
+
+// Resolve implements Resolver interface.
+func (kr *KeyResolver) Resolve(oc *OcFlat, ref string) (val []byte, ok bool, err error) {
+	if len(ref) < 2 || ref[0] != '/' {
+		return
+	}
+	if !kr.Tree.builtFor(oc) {
+		t := &OcTree{Oc: oc}
+		if kr.Tree != nil {
+			t.Dups = kr.Tree.Dups
+		}
+		if err = t.Build(); err != nil {
+			return nil, true, err
+		}
+		kr.Tree = t
+	}
+	n, err := kr.Tree.Lookup(ref)
+	switch {
+	case err != nil:
+		return nil, true, err
+	case n.Target().Kind != NodeLeaf:
+		return nil, true, errors.New(ref + " is not a key")
+	}
+	i := n.Target().Item
+	if kr.busy == nil {
+		kr.busy = make(map[int]bool)
+	}
+	if kr.busy[i] {
+		return nil, true, &OcError{Pos: oc.Items[i].Ns, Line: oc.ItemLine(i),
+			Msg: "reference cycle through " + ref}
+	}
+	kr.busy[i] = true
+	lv, err := oc.Logical(i)
+	delete(kr.busy, i)
+	return lv.Val, true, err
+}
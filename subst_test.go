@@ -0,0 +1,99 @@
+package octok
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ´ is replaced with a backtick
+const tSubst string = `  top : base
+ ^ Section : ---
+     home : ´$OCTOK_TEST_HOME´/bin ´.
+     path : ´/Section.home´:´/top´ ´.
+     tick : one ´´ tick\t ´\.
+   ^^ Sub : ---
+      key : ´/Section/path´/sub ´.
+     cyc1 : ´/Section/Sub.cyc2´ ´.
+     cyc2 : ´/Section/Sub/cyc1´ ´.
+     miss : ´/Nope.key´ ´.
+     open : ´/top ´.
+     unkn : ´who´ ´.
+`
+
+func TestBacktickSubst(t *testing.T) {
+	var oc OcFlat
+	os.Setenv("OCTOK_TEST_HOME", "/home/oc")
+	oc.Inbuf = []byte(strings.ReplaceAll(tSubst, "´", "`"))
+	oc.Resolvers = []Resolver{EnvResolver{}, &KeyResolver{}}
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Subst test config should parse but it did not!")
+	}
+	for i, exp := range []string{
+		"base", "---", "/home/oc/bin", "/home/oc/bin:base", "one ` tick\t", "---",
+		"/home/oc/bin:base/sub",
+	} {
+		if v, err := oc.Value(i); err != nil || string(v) != exp {
+			t.Errorf("Bad. Item %d should substitute to »%s« got »%s« (%v)", i, exp, v, err)
+		}
+	}
+	for i := 7; i < len(oc.Items); i++ {
+		if _, err := oc.Value(i); err == nil {
+			t.Errorf("Bad. Item %d »%s« should err but it did not!", i, oc.RawValue(i))
+		} else if e := err.(*OcError); e.Line != uint32(i+1) {
+			t.Errorf("Bad. Item %d error should be at line %d: %s", i, i+1, err)
+		}
+	}
+	os.Unsetenv("OCTOK_TEST_HOME")
+	if _, err := oc.Value(2); err == nil {
+		t.Errorf("Bad. Not set environment variable should err but it did not!")
+	}
+}
+
+func TestKeyResolverReuse(t *testing.T) {
+	var d OcDecoder
+	d.Oc.Resolvers = []Resolver{&KeyResolver{}}
+	var c struct{ A, B, C, D, E string }
+	if err := d.Decode([]byte("A : a\nB : b\nC : c\nD : d\n"), &c); err != nil {
+		t.Fatalf("Bad. First config should decode but it did not: %s", err)
+	}
+	if err := d.Decode([]byte("E : `/D` `.\n"), &c); err == nil || !strings.Contains(err.Error(), `no "D" found`) {
+		t.Errorf("Bad. Reference to a key of the former config should err, got: %v", err)
+	}
+	d.Tree.Dups = DupLast
+	d.Oc.LintFull = true
+	c = struct{ A, B, C, D, E string }{}
+	if err := d.Decode([]byte("A : x\nA : y\nB : `/A` `.\n"), &c); err != nil || c.B != "y" {
+		t.Errorf("Bad. Reference should resolve with the decoder Dups, got »%s« (%v)", c.B, err)
+	}
+	if len(d.Oc.Lapses) != 2 {
+		t.Errorf("Bad. Duplicates should be linted once each, got: %v", d.Oc.Lapses)
+	}
+	var oc OcFlat
+	kr := &KeyResolver{Tree: &OcTree{Dups: DupFirst}}
+	oc.Resolvers = []Resolver{kr}
+	for _, cf := range []string{"A : x\nA : y\nB : `/A` `.\n", "A : z\nB : `/A` `.\n"} {
+		Reset(&oc, []byte(cf), false)
+		if !oc.Tokenize() {
+			t.Fatalf("Bad. »%s« should tokenize but it did not!", cf)
+		}
+		if v, err := oc.Value(len(oc.Items) - 1); err != nil || v[0] != cf[4] {
+			t.Errorf("Bad. »%s« should resolve to »%c«, got »%s« (%v)", cf, cf[4], v, err)
+		}
+	}
+}
+
+func TestKeyResolverTarget(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte("b : =/c/.\nc : target\nd : =/S/.\ne : `/b` `.\nf : `/d` `.\n^ S : ---\ng : h\n")
+	oc.Resolvers = []Resolver{&KeyResolver{}}
+	if !oc.Tokenize() {
+		t.Fatalf("Bad. Referring config should parse but it did not!")
+	}
+	if v, err := oc.Value(3); err != nil || string(v) != "target" {
+		t.Errorf("Bad. `/b` should give the value of its target, got »%s« (%v)", v, err)
+	}
+	if _, err := oc.Value(4); err == nil || !strings.Contains(err.Error(), "not a key") {
+		t.Errorf("Bad. Reference to a section referrer should err, got: %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NodeKind tells what an OcNode stands for.
+type NodeKind byte
+
+const (
+	NodeRoot    NodeKind = iota // the config itself
+	NodeSection                 // ^ Section : lead and all items below
+	NodeLeaf                    // name : value item, with its joined lines
+	NodeGroup                   // ( group ), transparent for names and indexes
+	NodeDict                    // { dict }, of named members only
+	NodeList                    // [ list ], of ORD members only
+	NodeSet                     // < set >, of named and ORD members
+	NodeModel                   // @ Model : section to derive other ones from
+)
+
+// OcNode is a node of the config structure built by the OcTree Build.
+// Node refers to the Items it was made of, see OcFlat accessors for
+// names and values.
+type OcNode struct {
+	Kind  NodeKind
+	Name  string    // key, section or bracket name. Empty for ORD ones.
+	Index int       // index of an ORD member, -1 for a named one
+	Depth int       // section depth given by the lead carets
+	Item  int       // Item that made the node, -1 for the root
+	Last  int       // last Item of a leaf chain, or a bracket closer
+	Up    *OcNode   // parent node
+	Kids  []*OcNode // child nodes, in order
+	Base  *OcNode   // model a section derives from, or nil
+	From  *OcNode   // node an inherited one was copied from, or nil
+	Ref   *OcNode   // node a =…/ or &…/ meta refers to, or nil
+	Alias bool      // Ref is an &…/ alias, not an =…/ copy
+	next  int       // next natural ORD index of a container
+	top   int       // container's highest ORD index given, plus one
+}
+
+// func scope returns the node that n's members belong to: n itself or,
+// for a group, the first not group node up.
+func (n *OcNode) scope() *OcNode {
+	for n.Kind == NodeGroup {
+		n = n.Up
+	}
+	return n
+}
+
+// OcTree keeps config structure built over a tokenized OcFlat. It is
+// used like OcFlat is: set Oc, then call Build.
+//
+//	var t octok.OcTree
+//	t.Oc = &oc // after successful oc.Tokenize()
+//	if err := t.Build(); err != nil {
+//		// report error
+//	}
+type OcTree struct {
+	Oc   *OcFlat   // tokenized config
+	Root *OcNode   // config structure
+	Dups DupPolicy // what to do with keys given twice; see DupPolicy
+	over *OcItem   // first of the Oc.Items the Root was built over
+}
+
+// DupPolicy tells what Build should do with a key given more than once
+// within a section, dict or set.
+type DupPolicy byte
+
+const (
+	DupError   DupPolicy = iota // Build fails
+	DupLast                     // last one given is kept
+	DupFirst                    // first one given is kept
+	DupCollect                  // all are collected into a list in place of the first
+)
+
+// Build makes the config structure out of the Oc.Items. First it calls
+// Oc.ExpandGroups, then walks Items opening sections as their lead
+// carets tell: "^ Section :" opens at depth 1, "^^ SubSec :" at depth 2
+// under it and so on. A section closes when another section of the same
+// or lower depth opens. Items are put into the section that is open at
+// the moment; Items found before any section are put at the root.
+//
+// Section may be deeper by only one level than its parent. A deeper one
+// is linted with LintDepthJump then treated as if it was one level deeper.
+// Lints are registered in the Oc as Tokenize does.
+//
+// Within a section, bracket Items build nested nodes: "name { :" opens
+// a dict, "name [ :" a list, "name < :" a set and "( :" a group; each
+// closes at the matching "} :", "] :", "> :" or ") :" Item. A dict takes
+// named members only, a list ORD members only, a set takes both. Group
+// nodes are transparent: their members count as members of the node the
+// group is in. Mismatched and not closed brackets, members of a wrong
+// kind and section leads given within an open bracket are errors.
+//
+// ORD members (including brackets opened without a name) get an Index by
+// the order of appearance, unless the index is given explicit with an all
+// digits name, as in "33 : value" or "34 [ :". Next ORD member after an
+// explicit index gets the index that follows. Quoted digits, as in
+// "'33 : value", make a string key, not an index. Index given twice in a
+// container is an error. Explicit index lower than the next natural one
+// is linted with LintIndexBack.
+//
+// Model sections, led by "@" characters instead of carets, are built the
+// same way. A section (or a model) given a value of a single "@Name" word
+// derives from the Name model (other values, as "@admin only", are just a
+// decoration): it inherits all model members it does not have of its own,
+// with model's own derivations applied. Model is looked up among siblings
+// of the section, then of its parent and so on up to the root; it might be
+// given by a path too, eg. "@/Models/Name". Inherited nodes are copies
+// with From set to the node they came from, see Origin.
+//
+// Keys given more than once in the same section, dict or set are dealt
+// with as the Dups policy tells, before models are derived. Names are
+// compared in canonical form, with blank runs squeezed to a single space
+// and blanks around dots removed: "a  key", "a key" and "'a key" are
+// the same, so are "a.b" and "a . b". A dotted name is the same as the
+// name found by a dot split, as Lookup tells: "a.b" key is a duplicate
+// of the "b" member of the "a" dict. Every duplicate is linted with
+// LintDupKey, whatever the policy.
+//
+// Last, references given with metas to leaves are resolved, see Target.
+// Errors returned are of *OcError type.
+func (t *OcTree) Build() (err error) {
+	oc := t.Oc
+	t.over = nil
+	if err = oc.ExpandGroups(); err != nil {
+		return
+	}
+	t.Root = &OcNode{Kind: NodeRoot, Index: -1, Item: -1, Last: -1}
+	sects := []*OcNode{t.Root} // open sections, by depth
+	cur := t.Root              // open container
+	for i := 0; i < len(oc.Items); i++ {
+		n := &OcNode{Index: -1, Item: i, Last: i, Up: cur}
+		br := oc.bracketOf(i)
+		switch {
+		case oc.sectLead(i) > 0:
+			if !cur.isSection() {
+				return t.errAt(i, "section lead within the bracket opened at line "+
+					lineStr(oc.ItemLine(cur.Item)))
+			}
+			d := oc.sectLead(i)
+			if d > len(sects) {
+				oc.lint(i, LintDepthJump)
+				d = len(sects)
+			}
+			sects = sects[:d]
+			n.Kind = NodeSection
+			if oc.Inbuf[oc.Items[i].Ns] == SectLeadEx {
+				n.Kind = NodeModel
+			}
+			n.Name = strings.TrimSpace(string(oc.Name(i)[oc.sectLead(i):]))
+			n.Depth = d
+			n.Up = sects[d-1]
+			sects = append(sects, n)
+			cur = n
+			n.Up.Kids = append(n.Up.Kids, n)
+			continue
+		case br == ')' || br == ']' || br == '}' || br == '>':
+			if o := bracketKind[br]; cur.Kind != o {
+				if cur.isSection() {
+					return t.errAt(i, "closing "+string(br)+" has no opener")
+				}
+				return t.errAt(i, "closing "+string(br)+" does not match bracket opened at line "+
+					lineStr(oc.ItemLine(cur.Item)))
+			}
+			cur.Last = i
+			cur = cur.Up
+			continue
+		case br != 0:
+			n.Kind = bracketKind[br]
+			n.Name = strings.TrimSpace(string(oc.Name(i)[:oc.Items[i].Ne-oc.Items[i].Ns-1]))
+		default:
+			n.Kind = NodeLeaf
+			if oc.Items[i].Fl&(IsOrd|IsIndex) != IsOrd {
+				n.Name = oc.NameStr(i)
+			}
+			if n.Last, err = oc.chainEnd(i); err != nil {
+				return
+			}
+		}
+		if n.Kind != NodeGroup {
+			if err = t.member(n); err != nil {
+				return
+			}
+		}
+		cur.Kids = append(cur.Kids, n)
+		if n.Kind == NodeLeaf {
+			i = n.Last
+		} else {
+			cur = n
+		}
+	}
+	if !cur.isSection() {
+		return t.errAt(cur.Item, "bracket opened here is not closed")
+	}
+	if err = t.dedup(t.Root); err != nil {
+		return
+	}
+	if err = t.derive(); err != nil {
+		return
+	}
+	if err = t.refer(); err == nil && len(oc.Items) > 0 {
+		t.over = &oc.Items[0]
+	}
+	return
+}
+
+// func builtFor tells whether t was built over the current Items of oc.
+func (t *OcTree) builtFor(oc *OcFlat) bool {
+	return t != nil && t.Oc == oc && t.over != nil &&
+		len(oc.Items) > 0 && t.over == &oc.Items[0]
+}
+
+// func isSection tells whether n is a root, section or model node.
+func (n *OcNode) isSection() bool {
+	return n.Kind == NodeRoot || n.Kind == NodeSection || n.Kind == NodeModel
+}
+
+// bracketKind maps bracket characters to node kinds.
+var bracketKind = map[byte]NodeKind{
+	'(': NodeGroup, ')': NodeGroup,
+	'[': NodeList, ']': NodeList,
+	'{': NodeDict, '}': NodeDict,
+	'<': NodeSet, '>': NodeSet,
+}
+
+// func member checks whether n may be a member of its scope and gives
+// an index to the ORD one.
+func (t *OcTree) member(n *OcNode) error {
+	sc := n.Up.scope()
+	x := -1 // explicit index
+	if t.Oc.Items[n.Item].Fl&IsIndex != 0 && isDigits(n.Name) {
+		k, err := strconv.Atoi(n.Name)
+		if err != nil || k > maxIndex {
+			return t.errAt(n.Item, "index "+n.Name+" is out of range")
+		}
+		x, n.Name = k, ""
+	}
+	switch {
+	case sc.Kind == NodeDict && n.Name == "":
+		return t.errAt(n.Item, "unnamed member in a dict")
+	case sc.Kind == NodeList && n.Name != "":
+		return t.errAt(n.Item, "named member in a list")
+	case n.Name != "":
+		return nil
+	case x < 0:
+		x = sc.next
+	case x < sc.next:
+		t.Oc.lint(n.Item, LintIndexBack)
+	}
+	if x < sc.top {
+		if o := sc.ord(x); o != nil {
+			return t.errAt(n.Item, "index "+strconv.Itoa(x)+" is already given at line "+
+				lineStr(t.Oc.ItemLine(o.Item)))
+		}
+	}
+	n.Index = x
+	sc.next = x + 1
+	if sc.next > sc.top {
+		sc.top = sc.next
+	}
+	return nil
+}
+
+// maxIndex limits explicit indexes, so dense lists can be made of them.
+const maxIndex = 1<<20 - 1
+
+// Ords returns ORD members of n sorted by their Index, looking through
+// groups. Gaps left by explicit indexes are skipped.
+func (n *OcNode) Ords() (r []*OcNode) {
+	n.walkOrds(func(k *OcNode) { r = append(r, k) })
+	sort.SliceStable(r, func(a, b int) bool { return r[a].Index < r[b].Index })
+	return
+}
+
+// Dense returns ORD members of n placed at their Index, looking through
+// groups. Gaps left by explicit indexes are filled with nils.
+func (n *OcNode) Dense() (r []*OcNode) {
+	r = make([]*OcNode, n.top)
+	n.walkOrds(func(k *OcNode) { r[k.Index] = k })
+	return
+}
+
+// func walkOrds calls f for every ORD member of n.
+func (n *OcNode) walkOrds(f func(*OcNode)) {
+	for _, k := range n.Kids {
+		switch {
+		case k.Kind == NodeGroup:
+			k.walkOrds(f)
+		case k.Index >= 0:
+			f(k)
+		}
+	}
+}
+
+// func errAt makes an *OcError pointing at the i-th Item.
+func (t *OcTree) errAt(i int, msg string) error {
+	return &OcError{Pos: t.Oc.Items[i].Ns, Line: t.Oc.ItemLine(i), Msg: msg}
+}
+
+// func sectLead returns the number of lead carets (or model section "@"
+// characters) of a section lead Item, or 0 if the i-th Item is not
+// a section lead.
+func (oc *OcFlat) sectLead(i int) (d int) {
+	name := oc.Name(i)
+	if oc.Items[i].Fl&IsSpec == 0 || len(name) == 0 ||
+		name[0] != SectLead && name[0] != SectLeadEx {
+		return
+	}
+	for d < len(name) && name[d] == name[0] {
+		d++
+	}
+	return
+}
+
+// func lint registers lint flag f found at the i-th Item.
+func (oc *OcFlat) lint(i int, f LintFL) {
+	oc.LapsesFound++
+	if oc.LintFull {
+		oc.Lapses = append(oc.Lapses, OcLint{oc.ItemLine(i), f})
+	}
+}
@@ -0,0 +1,220 @@
+package octok
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const tSections string = `  top : before any section
+ ^ Section : ----- section lead ---
+    spaced :  val & spaces     |.
+    looong : value can span    +.
+           :  many lines and   +.
+           :: still keep indent.
+  ^^ SubSec : ---------------------
+            : list member  0
+   ^^^ SSSub : --------------------
+         key : value
+ ^ OthSect : ----------------------
+       key : value
+ ^^^ Jump : ---
+       key : value
+`
+
+// func treeDump shows node names with depth as indentation.
+func treeDump(n *OcNode, ind string, out *[]string) {
+	if n.Kind != NodeRoot {
+		*out = append(*out, ind+n.Name)
+		ind += " "
+	}
+	for _, k := range n.Kids {
+		treeDump(k, ind, out)
+	}
+}
+
+func TestSectionTree(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tSections)
+	oc.LintFull = true
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Sections test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Sections tree should build but it did not: %s", err)
+	}
+	var out []string
+	treeDump(tr.Root, "", &out)
+	exp := []string{"top", "Section", " spaced", " looong", " SubSec", "  ", "  SSSub", "   key",
+		"OthSect", " key", " Jump", "  key"}
+	if strings.Join(out, "|") != strings.Join(exp, "|") {
+		t.Errorf("Bad sections tree.\nExpected: %q\n     Got: %q", exp, out)
+	}
+	if n := tr.Root.Kids[1].Kids[1]; n.Item != 3 || n.Last != 5 {
+		t.Errorf("Bad. Joined leaf should span items 3..5, spans %d..%d", n.Item, n.Last)
+	}
+	if n := tr.Root.Kids[2].Kids[1]; n.Kind != NodeSection || n.Depth != 2 {
+		t.Errorf("Bad. Jumped section should be at depth 2, is at %d", n.Depth)
+	}
+	if oc.LapsesFound != 1 || len(oc.Lapses) != 1 || oc.Lapses[0] != (OcLint{13, LintDepthJump}) {
+		t.Errorf("Bad. Depth jump should be linted at line 13. Got %v", oc.Lapses)
+	}
+	Reset(&oc, []byte("k : v +.\n"), false)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Broken join config should tokenize but it did not!")
+	}
+	if err := tr.Build(); err == nil {
+		t.Errorf("Bad. Broken join should not build but it did!")
+	}
+}
+
+const tBrackets string = ` ^ Brackets : ----------------
+    dict { :
+         a : one
+         b : two
+      sub [ :
+           : first
+           ( : ^.
+           : second
+           : third
+           ) :
+             ] :
+    } :
+    set < :
+           : ord zero
+       key : named
+         [ :
+           : in anon list
+         ] :
+           : ord two
+         > :
+`
+
+func TestBracketTree(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tBrackets)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Brackets test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Brackets tree should build but it did not: %s", err)
+	}
+	var out []string
+	treeDump(tr.Root, "", &out)
+	exp := []string{"Brackets", " dict", "  a", "  b", "  sub", "   ", "   ", "    ", "    ",
+		" set", "  ", "  key", "  ", "   ", "  "}
+	if strings.Join(out, "|") != strings.Join(exp, "|") {
+		t.Errorf("Bad brackets tree.\nExpected: %q\n     Got: %q", exp, out)
+	}
+	sect := tr.Root.Kids[0]
+	dict, set := sect.Kids[0], sect.Kids[1]
+	if dict.Kind != NodeDict || set.Kind != NodeSet || dict.Last != 11 || set.Last != 19 {
+		t.Errorf("Bad. Dict and set should close at 11 and 19. Got %d %d", dict.Last, set.Last)
+	}
+	list := dict.Kids[2]
+	if list.Kind != NodeList || list.Kids[1].Kind != NodeGroup || list.Kids[1].Index != -1 {
+		t.Errorf("Bad. List should have a group within.")
+	}
+	if g := list.Kids[1]; g.Kids[0].Index != 1 || g.Kids[1].Index != 2 {
+		t.Errorf("Bad. Group members should be indexed as list members.")
+	}
+	for k, exp := range []int{0, -1, 1, 2} {
+		if set.Kids[k].Index != exp {
+			t.Errorf("Bad. Set member %d should have index %d, has %d", k, exp, set.Kids[k].Index)
+		}
+	}
+	for _, bad := range []struct {
+		conf string
+		line uint32
+	}{
+		{"d { :\n : ord\n } :\n", 2},
+		{"l [ :\n k : named\n ] :\n", 2},
+		{"d { :\n [ :\n ] :\n } :\n", 2},
+		{"d { :\n ] :\n", 2},
+		{" ] :\n", 1},
+		{"k : v\nd { :\n", 2},
+		{"d < :\n ^ Sect :\n > :\n", 2},
+	} {
+		Reset(&oc, []byte(bad.conf), false)
+		if ok := oc.Tokenize(); !ok {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad.conf)
+			continue
+		}
+		if err := tr.Build(); err == nil {
+			t.Errorf("Bad. »%s« should not build but it did!", bad.conf)
+		} else if err.(*OcError).Line != bad.line {
+			t.Errorf("Bad. »%s« should err at line %d, got: %s", bad.conf, bad.line, err)
+		}
+	}
+}
+
+const tIndexes string = ` ^ SubSec : ---
+          : member 0
+          : member 1
+       33 : member 33
+          : member 34
+      '33 : string key
+      2nd : named too
+       36 [ :
+          ] :
+       10 : back to 10
+`
+
+func TestOrdIndexes(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tIndexes)
+	oc.LintFull = true
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Indexes test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Indexes tree should build but it did not: %s", err)
+	}
+	sect := tr.Root.Kids[0]
+	var got []int
+	for _, n := range sect.Ords() {
+		got = append(got, n.Index)
+	}
+	if fmt.Sprint(got) != "[0 1 10 33 34 36]" {
+		t.Errorf("Bad ORD indexes: %v", got)
+	}
+	if n, err := tr.Lookup("/SubSec/33"); err != nil || oc.NameStr(n.Item) != "33" || n.Kind != NodeLeaf {
+		t.Errorf("Bad. Explicit index 33 should be found (%v)", err)
+	}
+	if n := sect.Kids[4]; n.Name != "33" || n.Index != -1 {
+		t.Errorf("Bad. Quoted '33 should be a string key, got %q %d", n.Name, n.Index)
+	}
+	if n := sect.Kids[5]; n.Name != "2nd" || n.Index != -1 {
+		t.Errorf("Bad. 2nd should be a string key, got %q %d", n.Name, n.Index)
+	}
+	d := sect.Dense()
+	if len(d) != 37 || d[2] != nil || d[36] == nil || d[36].Kind != NodeList || d[10] == nil {
+		t.Errorf("Bad dense list of %d members", len(d))
+	}
+	if oc.LapsesFound != 1 || oc.Lapses[0] != (OcLint{10, LintIndexBack}) {
+		t.Errorf("Bad. Backward index should be linted at line 10. Got %v", oc.Lapses)
+	}
+	for _, bad := range []struct {
+		conf string
+		line uint32
+	}{
+		{" : zero\n : one\n1 : again\n", 3},
+		{"l [ :\n 5 : five\n 4 : four\n : five again\n ] :\n", 4},
+		{"99999999999999999999 : huge\n", 1},
+		{"d { :\n 1 : v\n } :\n", 2},
+	} {
+		Reset(&oc, []byte(bad.conf), false)
+		if ok := oc.Tokenize(); !ok {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad.conf)
+			continue
+		}
+		if err := tr.Build(); err == nil {
+			t.Errorf("Bad. »%s« should not build but it did!", bad.conf)
+		} else if err.(*OcError).Line != bad.line {
+			t.Errorf("Bad. »%s« should err at line %d, got: %s", bad.conf, bad.line, err)
+		}
+	}
+}
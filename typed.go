@@ -0,0 +1,110 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"strconv"
+	"strings"
+)
+
+// func itemType returns type character of the i-th Item or 0 if it is
+// not typed (or if its Tc holds carets or an error code).
+func (oc *OcFlat) itemType(i int) OcItemTc {
+	if tc := oc.Items[i].Tc; tc < TcHasCarets {
+		return tc
+	}
+	return 0
+}
+
+// Typed returns the logical value that starts at the i-th Item converted
+// to the Go type its type pragma character tells:
+//
+//	'-'  nil        value must be empty, eg. "name : -."
+//	'*'  []string   blank separated words
+//	'~'  float64
+//	','  []string   comma separated elements, with blanks trimmed
+//	'$'  uint64
+//	'#'  int64      0x 0o 0b prefixes and _ digit separators are allowed
+//	'?'  bool       true false yes no on off 1 0 (in any case)
+//	'"'  string     Go unquoted if given "in quotes"
+//
+// Untyped values are returned as a string. With OcFlat.NoTypes set every
+// value is returned as a string. Errors are of *OcError type.
+func (oc *OcFlat) Typed(i int) (v interface{}, err error) {
+	lv, err := oc.Logical(i)
+	if err != nil {
+		return
+	}
+	if oc.NoTypes {
+		return string(lv.Val), nil
+	}
+	if v, err = TypedValue(lv.Tc, lv.Val); err != nil {
+		it := &oc.Items[i]
+		err = &OcError{Pos: it.Vs, Line: oc.ItemLine(i), Msg: err.Error()}
+	}
+	return
+}
+
+// TypedValue converts val to the Go type the tc type character tells.
+// See Typed method for the table. Zero tc gives a string.
+func TypedValue(tc OcItemTc, val []byte) (v interface{}, err error) {
+	s := string(val)
+	t := strings.TrimSpace(s)
+	var e error
+	switch tc {
+	case 0:
+		return s, nil
+	case TcNull:
+		if t == "" {
+			return nil, nil
+		}
+		return nil, typedErr(tc, s, "value is not empty")
+	case TcWords:
+		return strings.Fields(s), nil
+	case TcList:
+		r := strings.Split(s, ",")
+		for k := range r {
+			r[k] = strings.TrimSpace(r[k])
+		}
+		return r, nil
+	case TcFloat:
+		if v, e = strconv.ParseFloat(t, 64); e == nil {
+			return
+		}
+	case TcUint:
+		if v, e = strconv.ParseUint(t, 0, 64); e == nil {
+			return
+		}
+	case TcInt:
+		if v, e = strconv.ParseInt(t, 0, 64); e == nil {
+			return
+		}
+	case TcBool:
+		switch strings.ToLower(t) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return nil, typedErr(tc, s, "not a bool")
+	case TcString:
+		if len(t) < 2 || t[0] != '"' || t[len(t)-1] != '"' {
+			return s, nil
+		}
+		if v, e = strconv.Unquote(t); e == nil {
+			return
+		}
+	default:
+		return nil, typedErr(tc, s, "unknown type character")
+	}
+	if ne, ok := e.(*strconv.NumError); ok {
+		e = ne.Err
+	}
+	return nil, typedErr(tc, s, e.Error())
+}
+
+func typedErr(tc OcItemTc, s, why string) error {
+	return &OcError{Msg: "value »" + s + "« typed " + string(tc) + ". is bad: " + why}
+}
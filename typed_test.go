@@ -0,0 +1,63 @@
+package octok
+
+import (
+	"reflect"
+	"testing"
+)
+
+const tTyped string = `     null : -.
+    words : a  b c   *.
+    float : 2.5e3  ~.
+     list : a, b ,c  ,.
+     uint : 0x10  $.
+      int : -1_000  #.
+     bool : Yes  ?.
+   quoted : "tab\there"  ".
+    plain : "as is
+    joins : 12 +.
+          : 34  #.
+      bad : 12x  #.
+     over : 1  +#.
+          : 2  ?.
+`
+
+func TestTyped(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tTyped)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Typed test config should parse but it did not!")
+	}
+	for i, exp := range []interface{}{
+		nil,
+		[]string{"a", "b", "c"},
+		float64(2500),
+		[]string{"a", "b", "c"},
+		uint64(16),
+		int64(-1000),
+		true,
+		"tab\there",
+		`"as is`,
+		int64(1234),
+	} {
+		if v, err := oc.Typed(i); err != nil || !reflect.DeepEqual(v, exp) {
+			t.Errorf("Bad. Item %d should be typed to %#v, got %#v (%v)", i, exp, v, err)
+		}
+	}
+	if _, err := oc.Typed(11); err == nil {
+		t.Errorf("Bad. Not a number should err but it did not!")
+	} else if e := err.(*OcError); e.Line != 12 {
+		t.Errorf("Bad. Error should point at line 12: %s", err)
+	}
+	if _, err := oc.Typed(12); err == nil {
+		t.Errorf("Bad. Different types in a joined value should err but did not!")
+	}
+	oc.NoTypes = true
+	if v, err := oc.Typed(5); err != nil || v != "-1_000" {
+		t.Errorf("Bad. With NoTypes value should be a string, got %#v (%v)", v, err)
+	}
+	for _, tc := range []byte{'-', '~', '$', '#', '?', '"', '!'} {
+		if _, err := TypedValue(tc, []byte(`"bad\"`)); err == nil {
+			t.Errorf("Bad. »\"bad\\\"« typed %c. should err but it did not!", tc)
+		}
+	}
+}
@@ -4,23 +4,27 @@ package octok
 // []Items filled with parsed Item offsets pointing into that buffer.
 // OcFlat typically is embedded in a some "Parser" or "Config" struct.
 type OcFlat struct {
-	Inbuf         []byte     // raw input buffer
-	Items         []OcItem   // parsed lines
-	Lapses        []OcLint   // lints found. Filled if LintFull is true.
-	BadLint       OcLint     // why !ok
-	Inpos         int        // parser position - updated on line pragma calls only.
-	InLine        uint32     // pragma call line
-	ItemsExpected uint32     // default 64
-	LapsesFound   uint32     // lints counter, incemented even if LintFull is false
-	LintFull      bool       // register lints. Otherwise just up LapsesFound.
-	AllowBinRaw   bool       // allow binary raw values, otherwise just \t\n\r\del
-	NoTypes       bool       // disallow all type chars: - * ~ , $ # ? "
-	NoMetas       bool       // disallow all metas:  @…; =…/ (…) […] {…} <…>
-	Pck           uint64     // reference linter must be configurable
-	Sck           uint64     // 32B wasted for production code, an hour
-	Mck           uint64     // of time NOT dealing with separate "for
-	Tck           uint64     // linter" type saved; per every person.
-	linePragmas   lpDispatch // registered line pragma handlers
+	Inbuf         []byte      // raw input buffer
+	Items         []OcItem    // parsed lines
+	Lapses        []OcLint    // lints found. Filled if LintFull is true.
+	BadLint       OcLint      // why !ok
+	Inpos         int         // parser position - updated on line pragma calls only.
+	InLine        uint32      // pragma call line
+	ItemsExpected uint32      // default 64
+	LapsesFound   uint32      // lints counter, incemented even if LintFull is false
+	LintFull      bool        // register lints. Otherwise just up LapsesFound.
+	AllowBinRaw   bool        // allow binary raw values, otherwise just \t\n\r\del
+	NoTypes       bool        // disallow all type chars: - * ~ , $ # ? "
+	NoMetas       bool        // disallow all metas:  @…; =…/ (…) […] {…} <…>
+	Resolvers     []Resolver  // backtick substitution resolvers, asked in order
+	KeepComments  bool        // fill Comments with comment lines and remarks
+	Comments      []OcComment // comments found. Filled if KeepComments is true.
+	Pck           uint64      // reference linter must be configurable
+	Sck           uint64      // 32B wasted for production code, an hour
+	Mck           uint64      // of time NOT dealing with separate "for
+	Tck           uint64      // linter" type saved; per every person.
+	linePragmas   lpDispatch  // registered line pragma handlers
+	groups        []int32     // innermost group opener of Items. See ExpandGroups.
 }
 
 // OcItem keeps an oconf's ITEM found within Inbuf by Tokenize().
@@ -42,7 +46,7 @@ type OcFlat struct {
 // further than 31 bytes from the Ns position.
 // It is not an error if there are more or further placed parts but those
 // will not be registered in Np. (Linter marks this condition with
-// 'LintKeyParts' flag.)
+// 'LintKeyParts' flag.) The NameParts method decodes Np back to parts.
 //
 // The Ps field is a helper field. After Tokenize() it is free to
 // be reused.
@@ -59,6 +63,72 @@ type OcItem struct {
 	Fl ItemFL //_1B flags
 } // 32B
 
+// OcPart describes a single part of an Item's name, as decoded from
+// the OcItem.Np field by the NameParts method.
+type OcPart struct {
+	S, E uint32 // part start and end positions within Inbuf
+	Dot  bool   // part was separated from the previous one with a dot
+}
+
+// OcError describes a problem found past the Tokenize stage: while an
+// Item value is being materialized or config structure is being built.
+type OcError struct {
+	Line uint32 // Inbuf line № the problem was spotted at
+	Pos  uint32 // Inbuf position of the offending byte
+	Msg  string // what is wrong
+}
+
+func (e *OcError) Error() string {
+	return "oconf line " + lineStr(e.Line) + ": " + e.Msg
+}
+
+// OcPathError tells which segment of a path given to the OcTree Lookup
+// could not be followed.
+type OcPathError struct {
+	Path string // path as given
+	Pos  int    // Path position of the failing segment
+	Msg  string // what is wrong
+}
+
+func (e *OcPathError) Error() string {
+	return "oconf path " + e.Path + ": " + e.Msg
+}
+
+// OcComment describes a comment line or an end of line remark, as kept
+// by Tokenize with the OcFlat.KeepComments set. Comment lines that lead
+// an Item (with no empty line between) document it, so does the remark
+// given on the Item line. Other comments are not given to any Item.
+type OcComment struct {
+	S, E  uint32 // Inbuf span, from the comment mark to the line end
+	Line  uint32 // Inbuf line №
+	Item  int    // Item documented, or -1
+	Trail bool   // end of line remark, not a comment line
+}
+
+// OcMeta describes a single meta, as found by the MetaList method.
+// Kind is the meta opening character, one of: @ = & ( [ { <
+type OcMeta struct {
+	Kind byte   // meta opener
+	S, E uint32 // inner text start and end positions within Inbuf
+	Item int    // Item the meta was given to
+}
+
+// OcRaw describes a :== raw multiline value, as given by the Raw method.
+type OcRaw struct {
+	Val         []byte // block content, processed as asked
+	Boundary    string // 8 bytes long boundary that ended the block
+	First, Last uint32 // Inbuf lines the block spans
+}
+
+// OcLogical is a value that may span many Items glued together with the
+// +. and %. join pragmas. It is made by the Logical method.
+type OcLogical struct {
+	Item, Last int      // first and last Item of the chain
+	Val        []byte   // value joined from all chained Items
+	Tc         OcItemTc // type character given in chain, 0 if none
+	Metas      [][]byte // metas of chained Items, in order. See Metas.
+}
+
 type OcItemNp = uint16
 
 const NpOverParts OcItemNp = 1 << 15 // Np has all its parts filled
@@ -75,6 +145,18 @@ const (
 	TcTypeAndNL OcItemTc = 0xc3   // ^ and type chars in same pragma
 )
 
+// Type characters, as kept in the OcItem.Tc field. See Typed method.
+const (
+	TcNull   OcItemTc = '-' // nil. Value must be empty.
+	TcWords  OcItemTc = '*' // []string of blank separated words
+	TcFloat  OcItemTc = '~' // float64
+	TcList   OcItemTc = ',' // []string of comma separated elements
+	TcUint   OcItemTc = '$' // uint64
+	TcInt    OcItemTc = '#' // int64
+	TcBool   OcItemTc = '?' // bool
+	TcString OcItemTc = '"' // string, Go unquoted if "quoted"
+)
+
 //
 
 // const SECT_LEAD keeps a character that is used as a Section marker.
@@ -132,6 +214,9 @@ const (
 	LintBadEndLin                     // No NL at the end of buffer. Last line had not registered.
 	LintBadBufLen                     // Buffer is too short or too long to parse.
 	LintNoBoundary                    // RawEnd boundary could NOT be found!
+	LintDepthJump                     // Section is deeper by more than one level than its parent.
+	LintIndexBack                     // Explicit index is lower than the next natural one.
+	LintDupKey                        // Key is given more than once in the same scope.
 	LintUnknown                       // Test suite sentinel. Keep it at last entry.
 	LintOK         LintFL = 0         // Linted OK.
 )
@@ -159,6 +244,9 @@ func LintMessage(l LintFL) (r string) {
 		`No NL at the end of buffer. Last line had not registered.`,
 		`Buffer is too short or too long to parse.`,
 		`RawEnd boundary could NOT be found!`,
+		`Section is deeper by more than one level than its parent.`,
+		`Explicit index is lower than the next natural one.`,
+		`Key is given more than once in the same scope.`,
 		`Test suite sentinel. Keep it at last entry.`,
 	}
 	// yank constants, paste, vselect from 1 then: s#^.\+// #`# | '<,'>s#$#`,#
@@ -255,6 +343,36 @@ type LpCallParam = interface{}
 //
 type LpHandler func(pch byte, oc *OcFlat, fpar interface{}) (ok bool)
 
+// Resolver gives the substitution for a `reference` found within a value
+// with the `. (backtick) pragma set. Resolvers registered in the
+// OcFlat.Resolvers are asked in order until one returns ok. A Resolver
+// should return !ok for a ref it does not know how to deal with, and an
+// error for a ref it knows but can not resolve. See EnvResolver and
+// KeyResolver.
+type Resolver interface {
+	Resolve(oc *OcFlat, ref string) (val []byte, ok bool, err error)
+}
+
+// Unmarshaler is implemented by types that decode their config value
+// on their own. Decode gives UnmarshalOconf the value as it was given
+// on the first line of the Item (raw) and the logical value, with its
+// pragmas applied, type character and metas. Types that do not implement
+// Unmarshaler, but the encoding.TextUnmarshaler, get the lv.Val given to
+// the UnmarshalText.
+type Unmarshaler interface {
+	UnmarshalOconf(raw []byte, lv OcLogical) error
+}
+
+// Marshaler is implemented by types that give their config value on
+// their own. Marshal writes the val, adding pragmas the val needs to
+// tokenize back as given, then the pragmas returned; these may hold
+// type characters, the ` and ^ pragmas, and metas, eg. "#@unit;". Types
+// that do not implement Marshaler, but the encoding.TextMarshaler, are
+// written as MarshalText tells.
+type Marshaler interface {
+	MarshalOconf() (val []byte, pragmas string, err error)
+}
+
 // This struct is used as a parameter to the LinterSetup. fine-tune the Linter.
 // 		type LiPrCh = LinterPragmaChars // yet better alias it in your test code.
 // P, T, M are used to shrink the sets (restrict to the ones given).
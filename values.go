@@ -0,0 +1,259 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+// LineOf returns the Inbuf line № (counted from 1) of the pos position.
+func (oc *OcFlat) LineOf(pos uint32) uint32 {
+	if int(pos) > len(oc.Inbuf) {
+		pos = uint32(len(oc.Inbuf))
+	}
+	return uint32(bytes.Count(oc.Inbuf[:pos], []byte{'\n'})) + 1
+}
+
+// ItemLine returns the Inbuf line № of the i-th Item. For a :== item it
+// is the line of its header.
+func (oc *OcFlat) ItemLine(i int) uint32 {
+	return oc.LineOf(oc.Items[i].Ns)
+}
+
+func lineStr(ln uint32) string {
+	return strconv.FormatUint(uint64(ln), 10)
+}
+
+// Value returns the value of the i-th Item materialized as its value
+// pragmas tell: with `backtick` references substituted for `. and with
+// the rest unescaped for \. then with as many newlines appended as
+// carets were given with the ^. pragma. It works on a single Item - see
+// Logical for values that span many lines. The :== raw value is given
+// verbatim, except for carets. Items that Tokenize marked with one of
+// the Tc error codes are refused.
+//
+// Errors returned are of *OcError type, with Line and Pos filled.
+func (oc *OcFlat) Value(i int) (v []byte, err error) {
+	it := &oc.Items[i]
+	v = oc.RawValue(i)
+	if it.Tc&TcHasError == TcHasError {
+		e := &OcError{Pos: it.Ps, Line: oc.ItemLine(i)}
+		switch it.Tc {
+		case TcTooManyNL:
+			e.Msg = "more than 63 carets given"
+		case TcDoublType:
+			e.Msg = "more than one type character given"
+		default: // TcTypeAndNL
+			e.Msg = "type character and ^ given in a single pragma"
+		}
+		return nil, e
+	}
+	if it.Fl&(Unescape|Backtick) != 0 && !oc.IsRaw(i) {
+		if v, err = oc.subst(i, v); err != nil {
+			return
+		}
+	}
+	if it.Tc&TcHasCarets != 0 {
+		for n := it.Tc &^ TcHasCarets; n > 0; n-- {
+			v = append(v, '\n') // RawValue is capped, so it copies
+		}
+	}
+	return
+}
+
+// AppendUnesc appends src to dst with escape sequences replaced by the
+// characters they stand for. Recognized are the Go set of escapes:
+//
+//	\a \b \f \n \r \t \v  control characters
+//	\\                    backslash itself
+//	\xHH                  a byte given by two hex digits
+//	\uHHHH \UHHHHHHHH     utf-8 encoded unicode code point
+//
+// On a malformed sequence AppendUnesc returns *OcError with Pos set to
+// the src offset of the offending backslash and Line left zeroed.
+func AppendUnesc(dst, src []byte) ([]byte, error) {
+	for p := 0; p < len(src); p++ {
+		c := src[p]
+		if c != 0x5c {
+			dst = append(dst, c)
+			continue
+		}
+		if p+1 >= len(src) {
+			return dst, &OcError{Pos: uint32(p), Msg: `lone \ at the value end`}
+		}
+		var n int // hex digits to read
+		switch src[p+1] {
+		case 'a':
+			c = 0x07
+		case 'b':
+			c = 0x08
+		case 'f':
+			c = 0x0c
+		case 'n':
+			c = 0x0a
+		case 'r':
+			c = 0x0d
+		case 't':
+			c = 0x09
+		case 'v':
+			c = 0x0b
+		case 0x5c:
+			c = 0x5c
+		case 'x':
+			n = 2
+		case 'u':
+			n = 4
+		case 'U':
+			n = 8
+		default:
+			return dst, &OcError{Pos: uint32(p),
+				Msg: `unknown escape \` + string(src[p+1:p+2])}
+		}
+		if n == 0 {
+			dst = append(dst, c)
+			p++
+			continue
+		}
+		if p+2+n > len(src) {
+			return dst, &OcError{Pos: uint32(p),
+				Msg: `too few hex digits in \` + string(src[p+1:])}
+		}
+		var r rune
+		for _, h := range src[p+2 : p+2+n] {
+			switch {
+			case h >= '0' && h <= '9':
+				h -= '0'
+			case h|0x20 >= 'a' && h|0x20 <= 'f':
+				h = h | 0x20 - 'a' + 10
+			default:
+				return dst, &OcError{Pos: uint32(p),
+					Msg: `bad hex digit in \` + string(src[p+1:p+2+n])}
+			}
+			r = r<<4 | rune(h)
+		}
+		switch {
+		case n == 2:
+			dst = append(dst, byte(r))
+		case utf8.ValidRune(r):
+			var u [utf8.UTFMax]byte
+			dst = append(dst, u[:utf8.EncodeRune(u[:], r)]...)
+		default:
+			return dst, &OcError{Pos: uint32(p),
+				Msg: `invalid code point \` + string(src[p+1:p+2+n])}
+		}
+		p += 1 + n
+	}
+	return dst, nil
+}
+
+// Logical returns the logical value that starts at the i-th Item. If the
+// Item has a +. (join) pragma, value of the next Item is appended to its
+// own, with no space added - the ORD separator ": " eats a single space
+// only, so the continuation line keeps any other leading blanks, and the
+// :: separator keeps them all. The %. (meta join) pragma makes the next
+// line a continuation of metas only, so that line's value must be empty.
+// Chain goes on as long as chained Items have join pragmas set. Type
+// character may be given at any line of the chain, but all typed lines
+// must agree on it.
+//
+// Continuation lines must be plain ORD ones, Logical errs if a chain runs
+// into a named, indexed or structure Item or off the end of Items.
+// Structure Items (with IsSpec flag) never start a chain: the join pragma
+// given to a group opener belongs to group members, not to the opener.
+func (oc *OcFlat) Logical(i int) (lv OcLogical, err error) {
+	var v []byte
+	lv.Item, lv.Last = i, i
+	if v, err = oc.Value(i); err != nil {
+		return
+	}
+	if m := oc.Metas(i); len(m) > 0 {
+		lv.Metas = append(lv.Metas, m)
+	}
+	lv.Tc = oc.itemType(i)
+	fl := oc.Items[i].Fl
+	if fl&IsSpec != 0 || fl&(NextCont|NextMeta) == 0 {
+		lv.Val = v
+		return
+	}
+	lv.Val = append(make([]byte, 0, 2*len(v)), v...)
+	for {
+		at := lv.Last
+		n, err := oc.joinNext(at)
+		if n < 0 || err != nil {
+			return lv, err
+		}
+		if lv.Tc != 0 && oc.itemType(n) != 0 && oc.itemType(n) != lv.Tc {
+			return lv, &OcError{Pos: oc.Items[at].Ps, Line: oc.ItemLine(at),
+				Msg: "joined line at " + lineStr(oc.ItemLine(n)) + " has other type character"}
+		}
+		if oc.Items[at].Fl&NextCont != 0 {
+			if v, err = oc.Value(n); err != nil {
+				return lv, err
+			}
+			lv.Val = append(lv.Val, v...)
+		}
+		if m := oc.Metas(n); len(m) > 0 {
+			lv.Metas = append(lv.Metas, m)
+		}
+		if lv.Tc == 0 {
+			lv.Tc = oc.itemType(n)
+		}
+		lv.Last = n
+	}
+}
+
+// func joinNext returns the index of the Item that continues a chain
+// after the at-th one, or -1 if chain ends there.
+func (oc *OcFlat) joinNext(at int) (n int, err error) {
+	fl := oc.Items[at].Fl
+	n = at + 1
+	switch {
+	case fl&(NextCont|NextMeta) == 0:
+		return -1, nil
+	case fl&NextCont != 0 && fl&NextMeta != 0:
+		err = &OcError{Msg: "both +. and %. joins given"}
+	case n == len(oc.Items):
+		err = &OcError{Msg: "join pragma runs off the end of config"}
+	case !oc.isPlainOrd(n):
+		err = &OcError{Msg: "join pragma runs into a named, index " +
+			"or structure item at line " + lineStr(oc.ItemLine(n))}
+	case fl&NextMeta != 0 && oc.Items[n].Fl&IsEmpty == 0:
+		err = &OcError{Msg: "line joined with %. has a value"}
+	}
+	if err != nil {
+		e := err.(*OcError)
+		e.Pos = oc.Items[at].Ps
+		e.Line = oc.LineOf(e.Pos)
+		return -1, err
+	}
+	return
+}
+
+// func chainEnd returns the index of the last Item of a chain that
+// starts at the i-th Item. Values are not materialized.
+func (oc *OcFlat) chainEnd(i int) (last int, err error) {
+	if oc.Items[i].Fl&IsSpec != 0 {
+		return i, nil
+	}
+	for n := i; n >= 0; n, err = oc.joinNext(last) {
+		last = n
+	}
+	return
+}
+
+// Logicals walks all OcFlat.Items and returns logical values found, in
+// order. It stops at the first error, returning values made so far.
+func (oc *OcFlat) Logicals() (r []OcLogical, err error) {
+	var lv OcLogical
+	for i := 0; i < len(oc.Items); i = lv.Last + 1 {
+		if lv, err = oc.Logical(i); err != nil {
+			return
+		}
+		r = append(r, lv)
+	}
+	return
+}
@@ -0,0 +1,159 @@
+package octok
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendUnesc(t *testing.T) {
+	var tEsc = []struct {
+		from, to string
+		bad      int // -1 if should unescape ok
+	}{
+		{`plain`, "plain", -1},
+		{`Use\v vtab and \n`, "Use\v vtab and \n", -1},
+		{`\a\b\f\n\r\t\v\\`, "\a\b\f\n\r\t\v\\", -1},
+		{`bell\x07 \x7E`, "bell\x07 ~", -1},
+		{`\u017c\U0001F600`, "ż😀", -1},
+		{`lone \`, "", 5},
+		{`bad \q`, "", 4},
+		{`short \x7`, "", 6},
+		{`nohex \xZZ`, "", 6},
+		{`surrogate \uD800`, "", 10},
+	}
+	for _, te := range tEsc {
+		r, err := AppendUnesc(nil, []byte(te.from))
+		switch {
+		case te.bad < 0 && err != nil:
+			t.Errorf("Bad. »%s« should unescape but it did not: %s", te.from, err)
+		case te.bad < 0 && string(r) != te.to:
+			t.Errorf("Bad. »%s« unescaped to »%q«, expected »%q«", te.from, r, te.to)
+		case te.bad >= 0 && err == nil:
+			t.Errorf("Bad. »%s« should NOT unescape but it did!", te.from)
+		case te.bad >= 0 && err.(*OcError).Pos != uint32(te.bad):
+			t.Errorf("Bad. »%s« error should point at %d, it points at %d", te.from, te.bad, err.(*OcError).Pos)
+		}
+	}
+}
+
+func TestValueUnescaped(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte("one : Use\\v vtab and \\n \\.\ntwo : keep \\n as is\n\n three : bad \\q here \\.\n")
+	if ok := oc.Tokenize(); !ok || len(oc.Items) != 3 {
+		t.Fatalf("Bad. Value test config should parse to 3 items but it did not!")
+	}
+	if v, err := oc.Value(0); err != nil || string(v) != "Use\v vtab and \n" {
+		t.Errorf("Bad. Value should be unescaped. Got »%q« (%v)", v, err)
+	}
+	if v, err := oc.Value(1); err != nil || string(v) != `keep \n as is` {
+		t.Errorf("Bad. Value should be left as is. Got »%q« (%v)", v, err)
+	}
+	_, err := oc.Value(2)
+	if err == nil {
+		t.Fatalf("Bad. Malformed escape should err but it did not!")
+	}
+	if e := err.(*OcError); e.Line != 4 || oc.Inbuf[e.Pos] != '\\' {
+		t.Errorf("Bad. Error should point at line 4 backslash. Got: %s @%d", e, e.Pos)
+	}
+	if oc.ItemLine(2) != 4 {
+		t.Errorf("Bad. Item 2 should be at line 4, got %d", oc.ItemLine(2))
+	}
+}
+
+const tJoins string = `    looong : value can span    +.   //  +  join
+           :  many lines and   +.   //
+           :: still keep indent.    // ::
+       ann : value %@one;.
+           : @two;.
+     trail : end
+`
+
+func TestLogicals(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tJoins)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Joins test config should parse but it did not!")
+	}
+	lvs, err := oc.Logicals()
+	if err != nil || len(lvs) != 3 {
+		t.Fatalf("Bad. Expected 3 logical values, got %d (%v)", len(lvs), err)
+	}
+	if s := string(lvs[0].Val); s != "value can span many lines and still keep indent." {
+		t.Errorf("Bad joined value: »%s«", s)
+	}
+	if lvs[0].Item != 0 || lvs[0].Last != 2 {
+		t.Errorf("Bad chain span: %d..%d", lvs[0].Item, lvs[0].Last)
+	}
+	if s := string(lvs[1].Val); s != "value" || len(lvs[1].Metas) != 2 ||
+		string(lvs[1].Metas[0]) != "@one;" || string(lvs[1].Metas[1]) != "@two;" {
+		t.Errorf("Bad meta joined value: »%s« %q", s, lvs[1].Metas)
+	}
+	if lvs[2].Item != 5 || string(lvs[2].Val) != "end" {
+		t.Errorf("Bad value after chains: »%s«", lvs[2].Val)
+	}
+	for _, bad := range []string{
+		"k : v +.\n",
+		"k : v +.\nn : v\n",
+		"k : v +.\n33 : v\n",
+		"k : v +.\n[ : v\n",
+		"k : v %.\n : v\n",
+	} {
+		Reset(&oc, []byte(bad), false)
+		if ok := oc.Tokenize(); !ok {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad)
+			continue
+		}
+		if _, err := oc.Logicals(); err == nil {
+			t.Errorf("Bad. »%s« should err but it did not!", bad)
+		} else if err.(*OcError).Line != 1 {
+			t.Errorf("Bad. »%s« should err at line 1, got: %s", bad, err)
+		}
+	}
+}
+
+func TestValueCarets(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(`    withNL : some value        ^.
+     three : three         ^^^.
+     guard : blanks   |^.
+      many : line one ^+.
+           :  line\ttwo \^+.
+           :  ends\t    ^.
+       raw :== RawBound ^.
+    text
+RawBound
+`)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Carets test config should parse but it did not!")
+	}
+	lvs, err := oc.Logicals()
+	if err != nil || len(lvs) != 5 {
+		t.Fatalf("Bad. Expected 5 logical values, got %d (%v)", len(lvs), err)
+	}
+	for n, exp := range []string{
+		"some value\n",
+		"three\n\n\n",
+		"blanks   \n",
+		"line one\n line\ttwo\n ends\\t\n",
+		"    text\n\n",
+	} {
+		if string(lvs[n].Val) != exp {
+			t.Errorf("Bad caret value %d. Expected »%q« got »%q«", n, exp, lvs[n].Val)
+		}
+	}
+	if string(oc.Inbuf[oc.Items[0].Vs:oc.Items[0].Pe]) != "some value        ^." {
+		t.Errorf("Bad. Appending newlines altered the Inbuf!")
+	}
+	for _, bad := range []string{
+		"k : v #^.\n",
+		"k : v #?.\n",
+		"k : v " + strings.Repeat("^", 64) + ".\n",
+	} {
+		Reset(&oc, []byte(bad), false)
+		if ok := oc.Tokenize(); !ok || len(oc.Items) != 1 {
+			t.Errorf("Bad. »%s« should tokenize but it did not!", bad)
+		} else if _, err := oc.Value(0); err == nil {
+			t.Errorf("Bad. »%s« should err but it did not!", bad)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Wojciech S. Czarnecki, OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT license that can be
+// found in the LICENSE file.
+
+package octok
+
+import "strconv"
+
+// WalkAct tells Walk how to go on after a WalkFn call.
+type WalkAct byte
+
+const (
+	WalkOn   WalkAct = iota // go on, into the node members
+	WalkSkip                // do not go into the node members
+	WalkStop                // stop the walk
+)
+
+// WalkFn is called by the Walk for every node, twice: with enter set on
+// the way in, then with enter cleared on the way out. Path is canonical,
+// as the Lookup takes it, eg. "/Section/list[3]"; the root node has the
+// "/" path. Depth is 0 for the root, 1 for its members and so on. Leave
+// call is not made for a node entered with WalkSkip or WalkStop returned.
+type WalkFn func(n *OcNode, path string, depth int, enter bool) WalkAct
+
+// Walk calls fn for every node of the tree, depth first, in order of
+// members. Group members are given paths and depth as members of the
+// node the group is in, the same as the group itself. Walk returns false
+// if fn stopped it.
+func (t *OcTree) Walk(fn WalkFn) bool {
+	return t.Root.walk("", 0, fn)
+}
+
+// func walk walks n given the path of the node n is a member of.
+func (n *OcNode) walk(path string, depth int, fn WalkFn) bool {
+	switch {
+	case n.Kind == NodeRoot:
+	case n.Kind == NodeGroup:
+	case n.Index >= 0 && path == "":
+		path = "/[" + strconv.Itoa(n.Index) + "]"
+	case n.Index >= 0:
+		path += "[" + strconv.Itoa(n.Index) + "]"
+	default:
+		path += "/" + n.Name
+	}
+	p := path
+	if p == "" {
+		p = "/"
+	}
+	switch fn(n, p, depth, true) {
+	case WalkStop:
+		return false
+	case WalkSkip:
+		return true
+	}
+	kd := depth + 1
+	if n.Kind == NodeGroup {
+		kd = depth
+	}
+	for _, k := range n.Kids {
+		if !k.walk(path, kd, fn) {
+			return false
+		}
+	}
+	return fn(n, p, depth, false) != WalkStop
+}
@@ -0,0 +1,65 @@
+package octok
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const tWalk string = `  top : root key
+      : root ord
+ ^ Section : ---
+      list [ :
+           : zero
+         ( : ^.
+           : one
+         ) :
+           ] :
+  ^^ Sub : ---
+     deep : down
+ ^ Other : ---
+      key : value
+`
+
+func TestWalk(t *testing.T) {
+	var oc OcFlat
+	oc.Inbuf = []byte(tWalk)
+	if ok := oc.Tokenize(); !ok {
+		t.Fatalf("Bad. Walk test config should parse but it did not!")
+	}
+	tr := OcTree{Oc: &oc}
+	if err := tr.Build(); err != nil {
+		t.Fatalf("Bad. Walk tree should build but it did not: %s", err)
+	}
+	var out []string
+	done := tr.Walk(func(n *OcNode, path string, depth int, enter bool) WalkAct {
+		if enter {
+			out = append(out, fmt.Sprintf("%d%s", depth, path))
+		} else if n.Kind != NodeLeaf {
+			out = append(out, "<")
+		}
+		if n.Name == "Sub" {
+			return WalkSkip
+		}
+		return WalkOn
+	})
+	exp := "0/ 1/top 1/[0] 1/Section 2/Section/list 3/Section/list[0] 3/Section/list" +
+		" 3/Section/list[1] < < 2/Section/Sub < 1/Other 2/Other/key < <"
+	if !done || strings.Join(out, " ") != exp {
+		t.Errorf("Bad walk.\nExpected: %s\n     Got: %s", exp, strings.Join(out, " "))
+	}
+	out = out[:0]
+	done = tr.Walk(func(n *OcNode, path string, depth int, enter bool) WalkAct {
+		out = append(out, path)
+		if _, err := tr.Lookup(path); err != nil {
+			t.Errorf("Bad. Walk path should be found by Lookup: %s", err)
+		}
+		if path == "/Section/list[0]" {
+			return WalkStop
+		}
+		return WalkOn
+	})
+	if done || len(out) != 8 {
+		t.Errorf("Bad. Walk should stop at the 8th call, made %d: %q", len(out), out)
+	}
+}